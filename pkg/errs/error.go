@@ -117,6 +117,9 @@ const (
 	// It is used when an authenticated user trying to access the resource
 	// but not permitted to do so
 	Unauthorized
+
+	Timeout  // Operation exceeded its deadline
+	Canceled // Operation canceled by the caller
 )
 
 func (k Kind) String() string {
@@ -145,6 +148,10 @@ func (k Kind) String() string {
 		return "unauthenticated_request"
 	case Unauthorized:
 		return "unauthorized_request"
+	case Timeout:
+		return "timeout_error"
+	case Canceled:
+		return "canceled_error"
 	}
 
 	return "unknown_error"
@@ -228,6 +228,8 @@ func HTTPStatusCodeFromError(err error) int {
 		return http.StatusUnauthorized
 	case Unauthorized:
 		return http.StatusForbidden
+	case Timeout:
+		return http.StatusGatewayTimeout
 	case Other, IO, Internal, Private, Database:
 		return http.StatusInternalServerError
 	default:
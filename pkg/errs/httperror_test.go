@@ -28,6 +28,8 @@ func TestHTTPStatusCodeFromError(t *testing.T) {
 		{"Invalid", args{k: errs.Invalid}, http.StatusNotAcceptable},
 		{"Unauthenticated", args{k: errs.Unauthenticated}, http.StatusUnauthorized},
 		{"Unauthorized", args{k: errs.Unauthorized}, http.StatusForbidden},
+		{"Timeout", args{k: errs.Timeout}, http.StatusGatewayTimeout},
+		{"Canceled", args{k: errs.Canceled}, http.StatusInternalServerError},
 		{"Other", args{k: errs.Other}, http.StatusInternalServerError},
 		{"Internal", args{k: errs.Internal}, http.StatusInternalServerError},
 		{"Database", args{k: errs.Database}, http.StatusInternalServerError},
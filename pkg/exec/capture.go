@@ -0,0 +1,157 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// CaptureMode defines which part of the output is kept once the capture limit is exceeded
+type CaptureMode uint8
+
+const (
+	// CaptureHead keeps the beginning of the output
+	CaptureHead CaptureMode = iota + 1
+	// CaptureTail keeps the end of the output
+	CaptureTail
+	// CaptureHeadTail keeps both the beginning and the end of the output, half of the limit each
+	CaptureHeadTail
+)
+
+// truncationMarker is placed where the output has been dropped
+const truncationMarker = "\n[... %d bytes truncated ...]\n"
+
+// capture is the in-memory capture of a task output, optionally bounded
+// to a limit and spilling the full output into a file
+type capture struct {
+	mu sync.Mutex
+
+	limit   int
+	headCap int
+
+	head  []byte
+	ring  []byte
+	pos   int
+	total int
+
+	spill *os.File
+}
+
+// newCapture returns a new capture bounded to the limit, a zero limit means unbounded
+func newCapture(mode CaptureMode, limit int) *capture {
+	c := &capture{limit: limit}
+
+	switch mode {
+	case CaptureHead:
+		c.headCap = limit
+	case CaptureTail:
+		c.headCap = 0
+	case CaptureHeadTail:
+		c.headCap = limit / 2
+	}
+
+	return c
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.spill != nil {
+		if _, err := c.spill.Write(p); err != nil {
+			return 0, err
+		}
+	}
+
+	c.total += len(p)
+	if c.limit <= 0 {
+		c.head = append(c.head, p...)
+		return len(p), nil
+	}
+
+	n := len(p)
+	if room := c.headCap - len(c.head); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+
+		c.head = append(c.head, p[:room]...)
+		p = p[room:]
+	}
+
+	c.writeRing(p)
+	return n, nil
+}
+
+// writeRing writes into the ring buffer keeping the latest bytes
+func (c *capture) writeRing(p []byte) {
+	size := c.limit - c.headCap
+	if size <= 0 || len(p) == 0 {
+		return
+	}
+
+	if c.ring == nil {
+		c.ring = make([]byte, 0, size)
+	}
+
+	if len(p) >= size {
+		c.ring = append(c.ring[:0], p[len(p)-size:]...)
+		c.pos = 0
+		return
+	}
+
+	// the ring is not full yet
+	if room := size - len(c.ring); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+
+		c.ring = append(c.ring, p[:room]...)
+		p = p[room:]
+	}
+
+	for len(p) > 0 {
+		n := copy(c.ring[c.pos:], p)
+		p = p[n:]
+		c.pos = (c.pos + n) % size
+	}
+}
+
+// String returns the captured output, with a marker where the output has been dropped
+func (c *capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tail := append(append([]byte{}, c.ring[c.pos:]...), c.ring[:c.pos]...)
+	if dropped := c.total - len(c.head) - len(tail); dropped > 0 {
+		return string(c.head) + fmt.Sprintf(truncationMarker, dropped) + string(tail)
+	}
+
+	return string(c.head) + string(tail)
+}
+
+// Truncated reports whether part of the output has been dropped
+func (c *capture) Truncated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.total > len(c.head)+len(c.ring)
+}
+
+// close closes the spill file and returns its path, the file is removed
+// when the output has not been truncated as it is entirely captured in-memory
+func (c *capture) close() string {
+	if c.spill == nil {
+		return ""
+	}
+
+	c.spill.Close()
+
+	name := c.spill.Name()
+	if !c.Truncated() {
+		os.Remove(name)
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,39 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/ardikabs/go-stdlib/pkg/shellwords"
+)
+
+// NewCommandLine returns a new Task from the command line, split into the command and
+// its arguments following the POSIX shell quoting rules, without invoking a shell.
+// The arguments set by WithArgs are appended to the ones from the command line.
+func NewCommandLine(line string, opts ...Option) (*Task, error) {
+	words, err := shellwords.Split(line)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the command line: %w", err)
+	}
+
+	if len(words) == 0 {
+		return nil, fmt.Errorf("command line couldn't be empty")
+	}
+
+	t, err := NewExec(words[0], opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	t.args = append(words[1:], t.args...)
+	return t, nil
+}
+
+// MustCommandLine is like NewCommandLine but panics on error
+func MustCommandLine(line string, opts ...Option) *Task {
+	t, err := NewCommandLine(line, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
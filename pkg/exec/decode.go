@@ -0,0 +1,120 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// snippetSize is the maximum size of the output snippet attached to the decode errors
+const snippetSize = 64
+
+// DecodeJSON decodes the stdout as JSON into v
+func (r Result) DecodeJSON(v any) error {
+	if err := json.Unmarshal([]byte(r.Stdout), v); err != nil {
+		return fmt.Errorf("could not decode the output as JSON: %w, near %q", err, snippet(r.Stdout, jsonErrorOffset(err)))
+	}
+
+	return nil
+}
+
+// Lines returns the lines of the stdout, without the trailing newlines
+func (r Result) Lines() []string {
+	if r.Stdout == "" {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(r.Stdout, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
+
+// Fields returns the lines of the stdout split by the separator, or around
+// the whitespaces when the separator is empty. The blank lines are skipped.
+func (r Result) Fields(sep string) [][]string {
+	var fields [][]string
+	for _, line := range r.Lines() {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if sep == "" {
+			fields = append(fields, strings.Fields(line))
+		} else {
+			fields = append(fields, strings.Split(line, sep))
+		}
+	}
+
+	return fields
+}
+
+// jsonLinesDecoder decodes every non-blank stdout line as a JSON value, the decoding stops
+// at the first error, which is then reported by the Result
+type jsonLinesDecoder struct {
+	decode func(line []byte) error
+	lineNo int
+	err    error
+}
+
+func (d *jsonLinesDecoder) line(line string) error {
+	d.lineNo++
+	if d.err != nil || strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	if err := d.decode([]byte(line)); err != nil {
+		d.err = fmt.Errorf("could not decode the output line %d: %w, near %q", d.lineNo, err, snippet(line, jsonErrorOffset(err)))
+	}
+
+	// the error is not returned, so that the output is still captured
+	return nil
+}
+
+// jsonErrorOffset returns the input offset of the JSON decode error, if known
+func jsonErrorOffset(err error) int {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return int(syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return int(typeErr.Offset)
+	default:
+		return 0
+	}
+}
+
+// snippet returns a part of s around the offset, of at most snippetSize bytes
+func snippet(s string, offset int) string {
+	if len(s) <= snippetSize {
+		return s
+	}
+
+	start := offset - snippetSize/2
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetSize
+	if end > len(s) {
+		end = len(s)
+		start = end - snippetSize
+	}
+
+	out := s[start:end]
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(s) {
+		out += "..."
+	}
+
+	return out
+}
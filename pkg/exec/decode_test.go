@@ -0,0 +1,116 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ardikabs/go-stdlib/pkg/errs"
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultDecodeJSON(t *testing.T) {
+	t.Run("valid output", func(t *testing.T) {
+		result := Result{Stdout: `{"name": "web", "replicas": 3}` + "\n"}
+
+		var v struct {
+			Name     string `json:"name"`
+			Replicas int    `json:"replicas"`
+		}
+		assert.NoError(t, result.DecodeJSON(&v))
+		assert.Equal(t, "web", v.Name)
+		assert.Equal(t, 3, v.Replicas)
+	})
+
+	t.Run("invalid output includes a snippet", func(t *testing.T) {
+		result := Result{Stdout: `{"items": [` + strings.Repeat(`"a", `, 30) + `oops]}`}
+
+		var v map[string]any
+		err := result.DecodeJSON(&v)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `oops]}`)
+		assert.Contains(t, err.Error(), `"...`)
+	})
+}
+
+func TestResultLines(t *testing.T) {
+	assert.Nil(t, Result{}.Lines())
+	assert.Equal(t, []string{"a", "", "b c"}, Result{Stdout: "a\r\n\nb c\n"}.Lines())
+	assert.Equal(t, []string{"a", "b"}, Result{Stdout: "a\nb"}.Lines())
+
+	result := Result{Stdout: "NAME  READY\nweb   1/1\n\ndb:x:2\n"}
+	assert.Equal(t, [][]string{{"NAME", "READY"}, {"web", "1/1"}, {"db:x:2"}}, result.Fields(""))
+	assert.Equal(t, [][]string{{"NAME  READY"}, {"web   1/1"}, {"db", "x", "2"}}, result.Fields(":"))
+}
+
+func TestWithJSONLines(t *testing.T) {
+	type event struct {
+		ID int `json:"id"`
+	}
+
+	t.Run("every line is decoded", func(t *testing.T) {
+		var ids []int
+		result := MustExec(`printf '{"id":1}\n\n{"id":2}\n{"id":3}'`,
+			WithShell("/bin/sh"),
+			WithJSONLines(func(e event) error {
+				ids = append(ids, e.ID)
+				return nil
+			}),
+		).Execute()
+
+		assert.NoError(t, result.Err())
+		assert.Equal(t, []int{1, 2, 3}, ids)
+		assert.Equal(t, "{\"id\":1}\n\n{\"id\":2}\n{\"id\":3}", result.Stdout)
+	})
+
+	t.Run("decode error stops the decoding", func(t *testing.T) {
+		var ids []int
+		result := MustExec(`printf '{"id":1}\nnot json\n{"id":3}\n'`,
+			WithShell("/bin/sh"),
+			WithJSONLines(func(e event) error {
+				ids = append(ids, e.ID)
+				return nil
+			}),
+		).Execute()
+
+		assert.Equal(t, []int{1}, ids)
+		assert.Equal(t, 0, result.ExitCode)
+		assert.Contains(t, result.Stdout, `{"id":3}`)
+
+		var e *errs.Error
+		err := result.Err()
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, CodeDecodeFailed, e.Code)
+		assert.Contains(t, err.Error(), "line 2")
+		assert.Contains(t, err.Error(), "not json")
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		result := MustExec(`echo '{"id":1}'`,
+			WithShell("/bin/sh"),
+			WithJSONLines(func(e event) error {
+				return fmt.Errorf("unexpected event %d", e.ID)
+			}),
+		).Execute()
+
+		assert.ErrorContains(t, result.Err(), "unexpected event 1")
+	})
+
+	t.Run("command failure takes precedence", func(t *testing.T) {
+		result := MustExec(`echo nope; exit 3`,
+			WithShell("/bin/sh"),
+			WithJSONLines(func(e event) error { return nil }),
+		).Execute()
+
+		var e *errs.Error
+		assert.True(t, errors.As(result.Err(), &e))
+		assert.Equal(t, CodeNonZeroExit, e.Code)
+	})
+
+	t.Run("nil handler", func(t *testing.T) {
+		_, err := NewExec("echo", WithJSONLines[event](nil))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,59 @@
+package exec
+
+import "github.com/ardikabs/go-stdlib/pkg/shellwords"
+
+// Plan renders the fully resolved command as a copy-pasteable shell line, made of the
+// working directory, the environment overrides and the shell wrapping, with the secrets redacted.
+// When the environment is not inherited, the line runs with `env -i` and the effective environment.
+func (t *Task) Plan() string {
+	command, args := t.resolve()
+
+	var envs []string
+	if t.envPolicy == EnvInheritAll {
+		for _, env := range t.env {
+			envs = setEnv(envs, env)
+		}
+	} else {
+		envs = t.environ(nil)
+	}
+
+	var words []string
+	if len(envs) > 0 || t.envPolicy != EnvInheritAll {
+		words = append(words, "env")
+		if t.envPolicy != EnvInheritAll {
+			words = append(words, "-i")
+		}
+		words = append(words, t.redact.env(envs)...)
+	}
+
+	words = append(words, command)
+	words = append(words, t.redact.args(args)...)
+
+	line := shellwords.Join(words)
+	if t.cwd != "" {
+		line = "cd " + shellwords.Quote(t.cwd) + " && " + line
+	}
+
+	return line
+}
+
+// dryRunResult returns the synthetic result of the task run in the dry-run mode
+func (t *Task) dryRunResult() Result {
+	command, args := t.resolve()
+
+	result := Result{
+		Command:  command,
+		Args:     args,
+		safeArgs: t.redact.args(args),
+		Env:      t.environ(nil),
+		DryRun:   true,
+		Plan:     t.Plan(),
+	}
+
+	if t.debug {
+		logger := t.baseLogger()
+		logger.Debug().Str("plan", result.Plan).Msg("dry run, the command is not executed")
+	}
+
+	return result
+}
@@ -0,0 +1,110 @@
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/ardikabs/go-stdlib/pkg/exec/exectest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Run("task is not executed", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "marker")
+
+		task := MustExec("touch", WithArgs(marker), WithDryRun())
+		result := task.Execute()
+
+		assert.NoError(t, result.Err())
+		assert.True(t, result.DryRun)
+		assert.Equal(t, "touch", result.Command)
+		assert.Equal(t, []string{marker}, result.Args)
+		assert.Equal(t, "touch "+marker, result.Plan)
+		assert.NoFileExists(t, marker)
+
+		p, err := task.Start()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, p.PID())
+		assert.True(t, p.Wait().DryRun)
+	})
+
+	t.Run("plan renders the directory, the environment and the shell", func(t *testing.T) {
+		task := MustExec(`echo "$GREETING" > out.txt`,
+			WithShell("/bin/bash"),
+			WithDirectory("/tmp/my dir"),
+			WithEnv("GREETING=hello world", "API_TOKEN=s3cret"),
+			WithDryRun(),
+		)
+
+		assert.Equal(t,
+			`cd '/tmp/my dir' && env 'GREETING=hello world' API_TOKEN=REDACTED /bin/bash -c 'echo "$GREETING" > out.txt'`,
+			task.Plan(),
+		)
+		assert.Equal(t, task.Plan(), task.Execute().Plan)
+	})
+
+	t.Run("plan without inherited environment", func(t *testing.T) {
+		os.Setenv("EXEC_DRY_RUN_ALLOWED", "yes")
+		defer os.Unsetenv("EXEC_DRY_RUN_ALLOWED")
+
+		task := MustExec("deploy",
+			WithArgs("--password", "hunter2", "--env=prod"),
+			WithEnvPolicy(EnvInheritAllowlist, "EXEC_DRY_RUN_ALLOWED"),
+			WithEnv("A=1"),
+		)
+		assert.Equal(t, "env -i EXEC_DRY_RUN_ALLOWED=yes A=1 deploy --password REDACTED --env=prod", task.Plan())
+
+		task = MustExec("true", WithEnvPolicy(EnvClean))
+		assert.Equal(t, "env -i true", task.Plan())
+	})
+
+	t.Run("plan quotes a command read as an assignment", func(t *testing.T) {
+		assert.Equal(t, `'FOO=bar' x`, MustExec("FOO=bar", WithArgs("x")).Plan())
+	})
+
+	t.Run("executor is not invoked", func(t *testing.T) {
+		f := exectest.NewExecutor(t)
+
+		task := MustExec("deploy", WithArgs("--env=prod"), WithExecutor(f.Exec), WithEnv("A=1"), WithDryRun())
+		assert.Equal(t, "env A=1 deploy --env=prod", task.Plan())
+
+		result := task.Execute()
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "env A=1 deploy --env=prod", result.Plan)
+		assert.NotContains(t, strings.Join(result.Env, " "), "EXECTEST_")
+
+		task = MustExec("deploy", WithExecutor(f.Exec), WithEnvPolicy(EnvClean), WithDryRun())
+		assert.Equal(t, "env -i deploy", task.Plan())
+		assert.Empty(t, task.Execute().Env)
+
+		assert.Empty(t, f.Calls())
+	})
+
+	t.Run("runner dry run", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "marker")
+
+		r, err := NewRunner(WithRunnerDryRun(), WithConcurrency(2))
+		assert.NoError(t, err)
+
+		results, err := r.Run(MustExec("touch", WithArgs(marker)), MustExec("false"))
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, "false", results[1].Plan)
+		assert.NoFileExists(t, marker)
+	})
+
+	t.Run("pipeline with a dry-run stage", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "marker")
+
+		p, err := NewPipeline([]*Task{MustExec("touch", WithArgs(marker)), MustExec("cat", WithDryRun())})
+		assert.NoError(t, err)
+
+		pr := p.Execute()
+		assert.True(t, pr.Results[0].DryRun)
+		assert.True(t, pr.Results[1].DryRun)
+		assert.NoFileExists(t, marker)
+	})
+}
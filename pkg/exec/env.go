@@ -0,0 +1,67 @@
+package exec
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvPolicy defines which environment variables of the current process are inherited by the task
+type EnvPolicy uint8
+
+const (
+	// EnvInheritAll inherits every environment variable, this is the default policy
+	EnvInheritAll EnvPolicy = iota
+	// EnvInheritAllowlist inherits only the allowed environment variables
+	EnvInheritAllowlist
+	// EnvClean inherits no environment variable
+	EnvClean
+)
+
+// splitEnv splits the environment variable on the first "=", as the value may contain "="
+func splitEnv(env string) (key, value string) {
+	key, value, _ = strings.Cut(env, "=")
+	return
+}
+
+// setEnv sets the environment variable into the list, replacing the existing one with the same key
+func setEnv(envs []string, env string) []string {
+	key, _ := splitEnv(env)
+	for i := range envs {
+		if k, _ := splitEnv(envs[i]); k == key {
+			envs[i] = env
+			return envs
+		}
+	}
+
+	return append(envs, env)
+}
+
+// environ returns the effective environment of the task, made of the environment
+// inherited following the policy, then the environment provided by the executor,
+// then the task environment overrides
+func (t *Task) environ(base []string) []string {
+	envs := []string{}
+
+	if t.envPolicy != EnvClean {
+		allowed := make(map[string]bool, len(t.envAllowlist))
+		for _, key := range t.envAllowlist {
+			allowed[key] = true
+		}
+
+		for _, env := range os.Environ() {
+			if key, _ := splitEnv(env); t.envPolicy == EnvInheritAll || allowed[key] {
+				envs = setEnv(envs, env)
+			}
+		}
+	}
+
+	for _, env := range base {
+		envs = setEnv(envs, env)
+	}
+
+	for _, env := range t.env {
+		envs = setEnv(envs, env)
+	}
+
+	return envs
+}
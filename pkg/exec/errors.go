@@ -0,0 +1,177 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ardikabs/go-stdlib/pkg/errs"
+)
+
+const (
+	// CodeNotFound means the command binary could not be found
+	CodeNotFound errs.Code = "command_not_found"
+	// CodeDirectoryNotFound means the working directory or the root directory of the command could not be found
+	CodeDirectoryNotFound errs.Code = "directory_not_found"
+	// CodeStartFailed means the command binary exists but could not be started, such as its interpreter is missing
+	CodeStartFailed errs.Code = "start_failed"
+	// CodePermissionDenied means the command binary could not be executed due to its permission
+	CodePermissionDenied errs.Code = "permission_denied"
+	// CodeKilled means the command has been killed by a signal
+	CodeKilled errs.Code = "killed_by_signal"
+	// CodeTimedOut means the command exceeded its deadline
+	CodeTimedOut errs.Code = "timed_out"
+	// CodeIdleTimedOut means the command has been terminated after being silent for too long
+	CodeIdleTimedOut errs.Code = "idle_timed_out"
+	// CodeCanceled means the command has been canceled
+	CodeCanceled errs.Code = "canceled"
+	// CodeLimitExceeded means the command has been killed after exceeding a resource limit
+	CodeLimitExceeded errs.Code = "limit_exceeded"
+	// CodeNonZeroExit means the command exited with non-zero exit code
+	CodeNonZeroExit errs.Code = "non_zero_exit"
+	// CodeDecodeFailed means the command succeeded but its output could not be decoded
+	CodeDecodeFailed errs.Code = "decode_failed"
+)
+
+// stderrTailLines is the number of the last stderr lines attached to the error
+const stderrTailLines = 5
+
+// waitStatus is implemented by the platform specific syscall.WaitStatus
+type waitStatus interface {
+	Signaled() bool
+	Signal() syscall.Signal
+}
+
+// Err returns nil when the task succeeded, otherwise an *errs.Error describing
+// the failure, with its Kind and Code set as follows:
+//
+//	errs.NotExist, CodeDirectoryNotFound
+//	errs.Internal, CodeStartFailed
+//	errs.NotExist, CodeNotFound
+//	errs.Unauthorized, CodePermissionDenied
+//	errs.Timeout, CodeTimedOut
+//	errs.Timeout, CodeIdleTimedOut
+//	errs.Canceled, CodeCanceled
+//	errs.Internal, CodeLimitExceeded
+//	errs.Internal, CodeKilled
+//	errs.Internal, CodeNonZeroExit
+//	errs.Invalid, CodeDecodeFailed
+//
+// The error message includes the tail of the stderr, if any.
+func (r Result) Err() error {
+	var (
+		kind errs.Kind
+		code errs.Code
+		msg  string
+	)
+
+	switch {
+	case r.TimedOut:
+		kind, code, msg = errs.Timeout, CodeTimedOut, "timed out"
+	case r.IdleKilled:
+		kind, code, msg = errs.Timeout, CodeIdleTimedOut, "terminated after being silent for the idle timeout"
+	case r.Canceled:
+		kind, code, msg = errs.Canceled, CodeCanceled, "canceled"
+	case errors.As(r.err, new(*dirError)):
+		return errs.E(errs.NotExist, CodeDirectoryNotFound, fmt.Errorf("command %q %w", r.Command, r.err))
+	case errors.As(r.err, new(*startError)):
+		return errs.E(errs.Internal, CodeStartFailed, fmt.Errorf("command %q %w", r.Command, r.err))
+	case errors.Is(r.err, exec.ErrNotFound), errors.Is(r.err, fs.ErrNotExist):
+		return errs.E(errs.NotExist, CodeNotFound, fmt.Errorf("command %q not found: %w", r.Command, r.err))
+	case errors.Is(r.err, fs.ErrPermission):
+		return errs.E(errs.Unauthorized, CodePermissionDenied, fmt.Errorf("command %q permission denied: %w", r.Command, r.err))
+	case r.LimitExceeded != 0:
+		kind, code, msg = errs.Internal, CodeLimitExceeded, fmt.Sprintf("killed by signal %d (%s) after exceeding the %s limit", int(r.Signal), r.Signal, r.LimitExceeded)
+	case r.Signal != 0:
+		kind, code, msg = errs.Internal, CodeKilled, fmt.Sprintf("killed by signal %d (%s)", int(r.Signal), r.Signal)
+	case r.ExitCode != 0:
+		kind, code, msg = errs.Internal, CodeNonZeroExit, fmt.Sprintf("exited with code %d", r.ExitCode)
+	case r.decodeErr != nil:
+		return errs.E(errs.Invalid, CodeDecodeFailed, fmt.Errorf("command %q %w", r.Command, r.decodeErr))
+	default:
+		return nil
+	}
+
+	msg = fmt.Sprintf("command %q %s", r.Command, msg)
+	if r.FailedLine > 0 {
+		msg += fmt.Sprintf(" at line %d", r.FailedLine)
+	}
+
+	if tail := tailLines(r.Stderr, stderrTailLines); tail != "" {
+		msg += ": " + tail
+	}
+
+	return errs.E(kind, code, msg)
+}
+
+// dirError is the start error of a command whose working directory or root directory is missing
+type dirError struct {
+	dir string
+	err error
+}
+
+func (e *dirError) Error() string {
+	return fmt.Sprintf("could not be started, directory %q not found: %v", e.dir, e.err)
+}
+
+func (e *dirError) Unwrap() error {
+	return e.err
+}
+
+// startError is the start error of a command whose binary exists, but could not be run
+type startError struct {
+	err error
+}
+
+func (e *startError) Error() string {
+	return fmt.Sprintf("could not be started: %v", e.err)
+}
+
+func (e *startError) Unwrap() error {
+	return e.err
+}
+
+// classifyStartError tells apart the causes of the start failing with ENOENT, which
+// Go reports against the command path: a missing working directory or root directory,
+// a missing command binary, or a missing interpreter of the command
+func classifyStartError(cmd *exec.Cmd, root string, err error) error {
+	if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, exec.ErrNotFound) {
+		return err
+	}
+
+	dirs := []string{root}
+	if cmd.Dir != "" {
+		dirs = append(dirs, filepath.Join(root, cmd.Dir))
+	}
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		if _, statErr := os.Stat(dir); errors.Is(statErr, fs.ErrNotExist) {
+			return &dirError{dir: dir, err: err}
+		}
+	}
+
+	if _, statErr := os.Stat(filepath.Join(root, cmd.Path)); statErr == nil {
+		return &startError{err: err}
+	}
+
+	return err
+}
+
+// tailLines returns the last n non-empty lines of s
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
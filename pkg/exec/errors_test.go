@@ -0,0 +1,109 @@
+package exec_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ardikabs/go-stdlib/pkg/errs"
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultErr(t *testing.T) {
+	noexec := filepath.Join(t.TempDir(), "noexec")
+	assert.NoError(t, os.WriteFile(noexec, []byte("#!/bin/sh\n"), 0o644))
+
+	nointerpreter := filepath.Join(t.TempDir(), "nointerpreter")
+	assert.NoError(t, os.WriteFile(nointerpreter, []byte("#!/does/not/exist\n"), 0o755))
+
+	testcases := []struct {
+		name     string
+		task     *Task
+		kind     errs.Kind
+		code     errs.Code
+		contains string
+	}{
+		{
+			name:     "binary not found",
+			task:     MustExec("command-does-not-exist"),
+			kind:     errs.NotExist,
+			code:     CodeNotFound,
+			contains: "not found",
+		},
+		{
+			name:     "working directory not found",
+			task:     MustExec("ls", WithDirectory("/does/not/exist")),
+			kind:     errs.NotExist,
+			code:     CodeDirectoryNotFound,
+			contains: `directory "/does/not/exist" not found`,
+		},
+		{
+			name:     "interpreter not found",
+			task:     MustExec(nointerpreter),
+			kind:     errs.Internal,
+			code:     CodeStartFailed,
+			contains: "could not be started",
+		},
+		{
+			name:     "permission denied",
+			task:     MustExec(noexec),
+			kind:     errs.Unauthorized,
+			code:     CodePermissionDenied,
+			contains: "permission denied",
+		},
+		{
+			name:     "killed by signal",
+			task:     MustExec("kill -9 $$", WithShell("/bin/sh")),
+			kind:     errs.Internal,
+			code:     CodeKilled,
+			contains: "killed by signal 9",
+		},
+		{
+			name:     "timed out",
+			task:     MustExec("sleep", WithArgs("5"), WithTimeout(50*time.Millisecond)),
+			kind:     errs.Timeout,
+			code:     CodeTimedOut,
+			contains: "timed out",
+		},
+		{
+			name:     "idle timed out",
+			task:     MustExec("sleep", WithArgs("5"), WithIdleTimeout(50*time.Millisecond)),
+			kind:     errs.Timeout,
+			code:     CodeIdleTimedOut,
+			contains: "idle timeout",
+		},
+		{
+			name:     "non-zero exit with the stderr tail",
+			task:     MustExec("for i in 1 2 3 4 5 6 7; do echo line$i >&2; done; exit 4", WithShell("/bin/sh")),
+			kind:     errs.Internal,
+			code:     CodeNonZeroExit,
+			contains: "exited with code 4: line3\nline4\nline5\nline6\nline7",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.task.Execute().Err()
+			assert.Error(t, err)
+			assert.True(t, errs.KindIs(tc.kind, err))
+
+			var e *errs.Error
+			assert.True(t, errors.As(err, &e))
+			assert.Equal(t, tc.code, e.Code)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+
+	t.Run("succeeded task", func(t *testing.T) {
+		assert.NoError(t, MustExec("true").Execute().Err())
+	})
+
+	t.Run("unwrapping the underlying error", func(t *testing.T) {
+		err := MustExec("command-does-not-exist").Execute().Err()
+		assert.True(t, errors.Is(err, exec.ErrNotFound))
+	})
+}
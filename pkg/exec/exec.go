@@ -1,21 +1,31 @@
 package exec
 
 import (
-	"bytes"
+	"context"
 	"io"
 	"os"
 	"os/exec"
-	"strings"
+	"syscall"
+	"time"
 
+	"github.com/ardikabs/go-stdlib/pkg/shellwords"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	defaultFailedCode = 1
+	defaultFailedCode  = 1
+	defaultGracePeriod = 5 * time.Second
 )
 
 type TaskExecutor func(command string, args ...string) *exec.Cmd
 
+// StartHook is called right before the task is started
+type StartHook func(ctx context.Context, t *Task)
+
+// FinishHook is called once the task has completed, along with its result
+type FinishHook func(ctx context.Context, t *Task, r Result)
+
 type Task struct {
 	exec TaskExecutor
 
@@ -24,27 +34,112 @@ type Task struct {
 	env     []string
 	cwd     string
 
+	envPolicy    EnvPolicy
+	envAllowlist []string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	lineHandler LineHandler
+	jsonLines   func(line []byte) error
+	prefix      string
+	prefixColor Color
+
+	captureMode  CaptureMode
+	captureLimit int
+	spill        bool
+	spillDir     string
+
+	redact       redactor
+	redactOutput bool
+
+	logger   *zerolog.Logger
+	onStart  []StartHook
+	onFinish []FinishHook
+
 	streamIO bool
 	debug    bool
+	dryRun   bool
 
 	shellExec string
 	shellMode bool
+	script    bool
+
+	timeout      time.Duration
+	gracePeriod  time.Duration
+	idleTimeout  time.Duration
+	retry        *retryPolicy
+	processGroup bool
+
+	procAttr procAttr
+	rlimits  []rlimit
+	pty      *ptySize
 }
 
 type Result struct {
 	Command string
 	Args    []string
-	Env     []string
+	// Env is the effective environment of the command
+	Env []string
 
 	Stdout   string
 	Stderr   string
 	ExitCode int
+
+	// Canceled reports whether the task was stopped because its context was canceled
+	Canceled bool
+	// TimedOut reports whether the task was stopped because it exceeded its deadline
+	TimedOut bool
+
+	// Truncated reports whether Stdout or Stderr has been truncated by the capture limit
+	Truncated bool
+	// StdoutFile and StderrFile are the paths of the files holding the full output,
+	// set only when the output spill is enabled and the output has been truncated.
+	// The caller is responsible for removing the files.
+	StdoutFile string
+	StderrFile string
+
+	// StartedAt is the time the command has been started, zero if it could not be started
+	StartedAt time.Time
+	// Duration is the wall-clock time elapsed from the start until the command exited
+	Duration time.Duration
+	// UserTime and SystemTime are the CPU time consumed by the command
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the maximum resident set size of the command in bytes, available on Linux
+	MaxRSS int64
+	// Signal is the signal which terminated the command, zero if it exited by itself
+	Signal syscall.Signal
+	// LimitExceeded is the resource limit which likely caused the termination of the command
+	LimitExceeded RlimitResource
+	// FailedLine is the line of the script body which failed, when known (see NewScript)
+	FailedLine int
+	// IdleKilled reports whether the command has been terminated after being silent
+	// for the idle timeout (see WithIdleTimeout)
+	IdleKilled bool
+	// Attempts holds the result of every attempt, including this last one,
+	// when the retries are enabled (see WithRetry)
+	Attempts []Result
+	// DryRun reports whether the command has been skipped by the dry-run mode (see WithDryRun)
+	DryRun bool
+	// Plan is the rendered command line of a dry run (see Task.Plan)
+	Plan string
+
+	// safeArgs holds the arguments with the secrets of the task redacted
+	safeArgs []string
+	// err is the error returned when starting or waiting for the command
+	err error
+	// decodeErr is the error of the JSON lines decoding, if any (see WithJSONLines)
+	decodeErr error
 }
 
 func NewExec(command string, opts ...Option) (*Task, error) {
 	t := &Task{
-		command: command,
-		exec:    exec.Command,
+		command:     command,
+		exec:        exec.Command,
+		stdin:       os.Stdin,
+		gracePeriod: defaultGracePeriod,
 	}
 
 	for _, o := range opts {
@@ -65,88 +160,58 @@ func MustExec(command string, opts ...Option) *Task {
 	return t
 }
 
-func (t *Task) Execute() (result Result) {
-	log := log.With().
-		Str("dir", t.cwd).
-		Str("cmd", t.command).
-		Str("args", strings.Join(t.args, " ")).
-		Logger()
-
-	var outbuf, errbuf bytes.Buffer
-
-	if t.shellMode {
-		if t.shellExec == "" {
-			t.shellExec = "/bin/sh"
-		}
-		t.args = append([]string{"-c", t.command}, t.args...)
-		t.command = t.shellExec
+// Execute runs the task and waits for it to complete
+func (t *Task) Execute() Result {
+	return t.ExecuteContext(context.Background())
+}
 
-		log = log.With().Str("shell", t.shellExec).Logger()
+// ExecuteContext runs the task and waits for it to complete, or until the context is done.
+// When the context is done, or the task timeout is exceeded, the process receives SIGTERM
+// and, if it is still running after the grace period, SIGKILL.
+func (t *Task) ExecuteContext(ctx context.Context) Result {
+	if t.retry != nil {
+		return t.executeWithRetry(ctx)
 	}
 
-	cmd := t.exec(t.command, t.args...)
-	cmd.Dir = t.cwd
-	cmd.Stdin = os.Stdin
+	p, _ := t.start(ctx)
+	return p.Wait()
+}
 
-	if t.streamIO {
-		cmd.Stdout = io.MultiWriter(os.Stdout, &outbuf)
-		cmd.Stderr = io.MultiWriter(os.Stderr, &errbuf)
-	} else {
-		cmd.Stdout = &outbuf
-		cmd.Stderr = &errbuf
+// withTimeout returns a derived context bounded by the task timeout, if any
+func (t *Task) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout > 0 {
+		return context.WithTimeout(ctx, t.timeout)
 	}
 
-	if len(t.env) > 0 {
-		log = log.With().
-			Str("env", strings.Join(t.env, ",")).
-			Logger()
+	return context.WithCancel(ctx)
+}
 
-		overrides := map[string]bool{}
-		for _, env := range t.env {
-			key := strings.Split(env, "=")[0]
-			overrides[key] = true
-			cmd.Env = append(cmd.Env, env)
-		}
+// baseLogger returns the logger of the task, by default the global logger
+func (t *Task) baseLogger() zerolog.Logger {
+	if t.logger != nil {
+		return *t.logger
+	}
 
-		for _, env := range os.Environ() {
-			key := strings.Split(env, "=")[0]
+	return log.Logger
+}
 
-			if _, ok := overrides[key]; !ok {
-				cmd.Env = append(cmd.Env, env)
-			}
-		}
-	}
+// String renders the task as a copy-pasteable command line, with the secrets redacted
+func (t *Task) String() string {
+	command, args := t.resolve()
+	return shellwords.Join(append([]string{command}, t.redact.args(args)...))
+}
 
-	if t.debug {
-		log.Debug().Msg("executing the command")
+// resolve returns the actual command and arguments to be executed,
+// wrapping them with the shell when the shell mode is enabled
+func (t *Task) resolve() (string, []string) {
+	if !t.shellMode {
+		return t.command, t.args
 	}
 
-	err := cmd.Run()
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitError.ExitCode()
-		} else {
-			// This will happen (in OSX) if `t.Command` is not available in $PATH,
-			// in this situation, exit code could not be get, and stderr will be
-			// empty string very likely, so we use the default fail code, and format err
-			// to string and set to stderr
-
-			if t.debug {
-				log.Debug().Msg("could not get exit code for failed command, return with default failed exit code")
-			}
-
-			result.ExitCode = defaultFailedCode
-			if result.Stderr == "" {
-				result.Stderr = err.Error()
-			}
-		}
+	shell := t.shellExec
+	if shell == "" {
+		shell = "/bin/sh"
 	}
 
-	result.Command = t.command
-	result.Args = t.args
-	result.Env = t.env
-	result.Stdout = outbuf.String()
-	result.Stderr = errbuf.String()
-
-	return
+	return shell, append([]string{"-c", t.command}, t.args...)
 }
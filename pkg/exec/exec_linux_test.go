@@ -0,0 +1,326 @@
+//go:build linux
+
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessGroup(t *testing.T) {
+	t.Run("timeout terminates the shell descendants", func(t *testing.T) {
+		tc, err := NewExec("sleep 5 & wait",
+			WithShell("/bin/sh"),
+			WithProcessGroup(),
+			WithTimeout(100*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result := tc.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.TimedOut)
+	})
+
+	t.Run("descendants ignoring SIGTERM are killed after grace period", func(t *testing.T) {
+		tc, err := NewExec("trap '' TERM; sleep 5 & wait",
+			WithShell("/bin/sh"),
+			WithProcessGroup(),
+			WithTimeout(100*time.Millisecond),
+			WithGracePeriod(100*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result := tc.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.TimedOut)
+	})
+}
+
+// alive reports whether the process is running, a zombie is not
+func alive(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestParentDeath(t *testing.T) {
+	// the parent process, run by the test below
+	if pidfile := os.Getenv("EXEC_TEST_PARENT_DEATH_PIDFILE"); pidfile != "" {
+		MustExec(`sleep 30 & echo $! > "$1"; wait`,
+			WithShell("/bin/sh"),
+			WithArgs("parent", pidfile),
+			WithProcessGroup(),
+		).Execute()
+		return
+	}
+
+	pidfile := filepath.Join(t.TempDir(), "pid")
+	parent := exec.Command(os.Args[0], "-test.run=^TestParentDeath$")
+	parent.Env = append(os.Environ(), "EXEC_TEST_PARENT_DEATH_PIDFILE="+pidfile)
+	assert.NoError(t, parent.Start())
+
+	var pid int
+	assert.Eventually(t, func() bool {
+		b, err := os.ReadFile(pidfile)
+		if err != nil {
+			return false
+		}
+
+		pid, err = strconv.Atoi(strings.TrimSpace(string(b)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	if !assert.True(t, pid > 0 && alive(pid)) {
+		return
+	}
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	assert.NoError(t, parent.Process.Kill())
+	_ = parent.Wait()
+
+	assert.Eventually(t, func() bool { return !alive(pid) }, 5*time.Second, 10*time.Millisecond,
+		"the descendants of the task should be killed along with the parent")
+}
+
+func TestProcAttr(t *testing.T) {
+	t.Run("running as a different user", func(t *testing.T) {
+		if os.Geteuid() != 0 {
+			t.Skip("requires root privileges")
+		}
+
+		result := MustExec("id", WithUser(65534, 65534, 65533)).Execute()
+		assert.Equal(t, 0, result.ExitCode, result.Stderr)
+		assert.Contains(t, result.Stdout, "uid=65534")
+		assert.Contains(t, result.Stdout, "gid=65534")
+		assert.Contains(t, result.Stdout, "65533")
+	})
+
+	t.Run("running in a new session along with the process group", func(t *testing.T) {
+		result := MustExec(`cut -d " " -f 6 /proc/$$/stat; echo $$`,
+			WithShell("/bin/sh"),
+			WithSetsid(),
+			WithProcessGroup(),
+		).Execute()
+		assert.Equal(t, 0, result.ExitCode, result.Stderr)
+
+		lines := strings.Fields(result.Stdout)
+		assert.Len(t, lines, 2)
+		assert.Equal(t, lines[1], lines[0], "the shell should be the session leader")
+	})
+
+	t.Run("running with the umask", func(t *testing.T) {
+		old := syscall.Umask(0o022)
+		defer syscall.Umask(old)
+
+		result := MustExec("umask", WithShell("/bin/sh"), WithUmask(0o077)).Execute()
+		assert.Equal(t, "0077\n", result.Stdout)
+		assert.Equal(t, "/bin/sh", result.Command)
+		assert.Equal(t, 0o022, syscall.Umask(0o022), "umask of the current process should be untouched")
+
+		result = MustExec("umask", WithShell("/bin/sh"), WithUmask(0o027), WithRlimit(RlimitNOFILE, 64, 64)).Execute()
+		assert.Equal(t, "0027\n", result.Stdout)
+	})
+
+	t.Run("umask is not applied to the current process", func(t *testing.T) {
+		old := syscall.Umask(0o022)
+		defer syscall.Umask(old)
+
+		dir := t.TempDir()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 20; i++ {
+				MustExec("true", WithUmask(0o777)).Execute()
+			}
+		}()
+
+		for running := true; running; {
+			select {
+			case <-done:
+				running = false
+			default:
+			}
+
+			f, err := os.CreateTemp(dir, "umask-*")
+			assert.NoError(t, err)
+			info, err := f.Stat()
+			assert.NoError(t, err)
+			f.Close()
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		}
+	})
+
+	t.Run("missing directories", func(t *testing.T) {
+		err := MustExec("/bin/ls", WithDirectory("/does/not/exist"), WithUmask(0o022)).Execute().Err()
+		assert.ErrorContains(t, err, `directory "/does/not/exist" not found`)
+
+		err = MustExec("/bin/ls", WithChroot("/does/not/exist")).Execute().Err()
+		assert.ErrorContains(t, err, `directory "/does/not/exist" not found`)
+	})
+
+	t.Run("invalid process attributes", func(t *testing.T) {
+		_, err := NewExec("true", WithChroot(""))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithUmask(0o1000))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithPdeathsig(0))
+		assert.Error(t, err)
+	})
+}
+
+func TestRlimit(t *testing.T) {
+	t.Run("exceeding the cpu limit", func(t *testing.T) {
+		result := MustExec("while :; do :; done",
+			WithShell("/bin/sh"),
+			WithRlimit(RlimitCPU, 1, 2),
+			WithTimeout(10*time.Second),
+		).Execute()
+
+		assert.False(t, result.TimedOut)
+		assert.Equal(t, RlimitCPU, result.LimitExceeded)
+		assert.ErrorContains(t, result.Err(), "exceeding the cpu limit")
+	})
+
+	t.Run("exceeding the file size limit", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+
+		result := MustExec(`exec head -c 4096 /dev/zero > "$OUT"`,
+			WithShell("/bin/sh"),
+			WithEnv("OUT="+out),
+			WithRlimit(RlimitFSIZE, 1024, 1024),
+		).Execute()
+		assert.Equal(t, RlimitFSIZE, result.LimitExceeded)
+
+		info, err := os.Stat(out)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1024))
+	})
+
+	t.Run("limiting the open files", func(t *testing.T) {
+		result := MustExec("ulimit -n",
+			WithShell("/bin/sh"),
+			WithRlimit(RlimitNOFILE, 64, 128),
+		).Execute()
+		assert.Equal(t, "64\n", result.Stdout)
+		assert.Equal(t, "/bin/sh", result.Command)
+		assert.Equal(t, []string{"-c", "ulimit -n"}, result.Args)
+		assert.Zero(t, result.LimitExceeded)
+	})
+
+	t.Run("limits are set before the command runs", func(t *testing.T) {
+		task := MustExec("ulimit -n", WithShell("/bin/sh"), WithRlimit(RlimitNOFILE, 10, 10))
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, "10\n", task.Execute().Stdout)
+		}
+	})
+
+	t.Run("command not found", func(t *testing.T) {
+		result := MustExec("command-does-not-exist", WithRlimit(RlimitNOFILE, 64, 64)).Execute()
+		assert.ErrorIs(t, result.Err(), exec.ErrNotFound)
+	})
+
+	t.Run("invalid resource limits", func(t *testing.T) {
+		_, err := NewExec("true", WithRlimit(RlimitResource(0), 1, 1))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithRlimit(RlimitCPU, 2, 1))
+		assert.Error(t, err)
+	})
+}
+
+func TestPTY(t *testing.T) {
+	t.Run("output is merged from the terminal", func(t *testing.T) {
+		result := MustExec(`[ -t 0 ] && [ -t 1 ] && echo tty; stty size; echo err >&2`,
+			WithShell("/bin/sh"),
+			WithPTY(24, 80),
+			WithStdin(nil),
+		).Execute()
+
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "tty\r\n24 80\r\nerr\r\n", result.Stdout)
+		assert.Empty(t, result.Stderr)
+	})
+
+	t.Run("resizing the window in a session", func(t *testing.T) {
+		s, err := NewSession(context.Background(), MustExec(`stty -echo; echo ready; read x; stty size`,
+			WithShell("/bin/sh"),
+			WithPTY(24, 80),
+		))
+		assert.NoError(t, err)
+
+		_, err = s.Expect(0, LiteralPattern("ready"))
+		assert.NoError(t, err)
+		assert.NoError(t, s.Process().Resize(40, 100))
+		assert.NoError(t, s.SendLine(""))
+
+		_, err = s.Expect(0, LiteralPattern("40 100"))
+		assert.NoError(t, err)
+		assert.NoError(t, s.Wait().Err())
+		assert.Error(t, s.Process().Resize(10, 10))
+	})
+
+	t.Run("stdin is left unread once the task completed", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		assert.NoError(t, err)
+		defer r.Close()
+		defer w.Close()
+
+		_, err = w.WriteString("first\n")
+		assert.NoError(t, err)
+
+		result := MustExec("read line; echo got $line", WithShell("/bin/sh"), WithPTY(24, 80), WithStdin(r)).Execute()
+		assert.NoError(t, result.Err())
+		assert.Contains(t, result.Stdout, "got first")
+
+		_, err = w.WriteString("next\n")
+		assert.NoError(t, err)
+
+		assert.NoError(t, r.SetReadDeadline(time.Now().Add(time.Second)))
+		buf := make([]byte, 16)
+		n, err := r.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "next\n", string(buf[:n]))
+	})
+
+	t.Run("invalid resize", func(t *testing.T) {
+		p, err := MustExec("exec sleep 5", WithShell("/bin/sh"), WithPTY(24, 80), WithStdin(nil)).Start()
+		assert.NoError(t, err)
+
+		assert.Error(t, p.Resize(-1, 80))
+		assert.Error(t, p.Resize(24, 70000))
+		assert.NoError(t, p.Resize(30, 100))
+
+		assert.NoError(t, p.Signal(syscall.SIGKILL))
+		p.Wait()
+	})
+
+	t.Run("process without pty", func(t *testing.T) {
+		p, err := MustExec("true").Start()
+		assert.NoError(t, err)
+		assert.Error(t, p.Resize(10, 10))
+		p.Wait()
+	})
+
+	t.Run("invalid window size", func(t *testing.T) {
+		_, err := NewExec("true", WithPTY(0, 80))
+		assert.Error(t, err)
+	})
+}
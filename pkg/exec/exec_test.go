@@ -1,9 +1,13 @@
 package exec_test
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/ardikabs/go-stdlib/pkg/exec"
 	"github.com/stretchr/testify/assert"
@@ -88,6 +92,315 @@ func TestExecute(t *testing.T) {
 		result := tc.Execute()
 		assert.Equal(t, "ping", result.Command)
 		assert.Len(t, result.Args, 3)
-		assert.Len(t, result.Env, 2)
+		assert.Contains(t, result.Env, "KEY=VALUE")
+		assert.Contains(t, result.Env, "KEY1=VALUE1")
+	})
+}
+
+func TestExecuteContext(t *testing.T) {
+	t.Run("task exceeding the timeout", func(t *testing.T) {
+		tc, err := NewExec("sleep", WithArgs("5"), WithTimeout(100*time.Millisecond))
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result := tc.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.TimedOut)
+		assert.False(t, result.Canceled)
+		assert.NotEqual(t, 0, result.ExitCode)
+	})
+
+	t.Run("task canceled by context", func(t *testing.T) {
+		tc, err := NewExec("sleep", WithArgs("5"))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		result := tc.ExecuteContext(ctx)
+		assert.True(t, result.Canceled)
+		assert.False(t, result.TimedOut)
+		assert.NotEqual(t, 0, result.ExitCode)
+	})
+
+	t.Run("task ignoring SIGTERM is killed after grace period", func(t *testing.T) {
+		tc, err := NewExec("trap '' TERM; exec sleep 5",
+			WithShell("/bin/sh"),
+			WithTimeout(100*time.Millisecond),
+			WithGracePeriod(100*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result := tc.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.TimedOut)
+		assert.Equal(t, -1, result.ExitCode)
+	})
+
+	t.Run("task silent for the idle timeout", func(t *testing.T) {
+		tc, err := NewExec("echo started; exec sleep 5",
+			WithShell("/bin/sh"),
+			WithIdleTimeout(200*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result := tc.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.IdleKilled)
+		assert.False(t, result.TimedOut)
+		assert.Equal(t, "started\n", result.Stdout)
+		assert.Equal(t, -1, result.ExitCode)
+	})
+
+	t.Run("task writing output is not idle", func(t *testing.T) {
+		tc, err := NewExec("for i in 1 2 3 4 5 6; do echo $i >&2; sleep 0.1; done",
+			WithShell("/bin/sh"),
+			WithIdleTimeout(300*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.NoError(t, result.Err())
+		assert.False(t, result.IdleKilled)
+	})
+
+	t.Run("task with already canceled context is not started", func(t *testing.T) {
+		tc, err := NewExec("echo", WithArgs("hello"))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result := tc.ExecuteContext(ctx)
+		assert.True(t, result.Canceled)
+		assert.Empty(t, result.Stdout)
+		assert.Equal(t, 1, result.ExitCode)
+	})
+
+	t.Run("task is executable more than once", func(t *testing.T) {
+		tc, err := NewExec("echo hello", WithShell("/bin/sh"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "hello\n", tc.Execute().Stdout)
+		assert.Equal(t, "hello\n", tc.Execute().Stdout)
+	})
+
+	t.Run("invalid timeout and grace period", func(t *testing.T) {
+		_, err := NewExec("sleep", WithTimeout(0))
+		assert.Error(t, err)
+
+		_, err = NewExec("sleep", WithGracePeriod(-time.Second))
+		assert.Error(t, err)
+
+		_, err = NewExec("sleep", WithIdleTimeout(0))
+		assert.Error(t, err)
+	})
+}
+
+func TestExecuteWithIO(t *testing.T) {
+	t.Run("feeding the standard input", func(t *testing.T) {
+		tc, err := NewExec("cat", WithStdin(strings.NewReader("hello")))
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, "hello", result.Stdout)
+	})
+
+	t.Run("reading from the null device", func(t *testing.T) {
+		tc, err := NewExec("cat", WithStdin(nil))
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, 0, result.ExitCode)
+		assert.Empty(t, result.Stdout)
+	})
+
+	t.Run("writing to the custom writers along with the capture", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		tc, err := NewExec("echo out; echo err >&2",
+			WithShell("/bin/sh"),
+			WithStdout(&stdout),
+			WithStderr(&stderr),
+		)
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, "out\n", result.Stdout)
+		assert.Equal(t, "err\n", result.Stderr)
+		assert.Equal(t, "out\n", stdout.String())
+		assert.Equal(t, "err\n", stderr.String())
+	})
+
+	t.Run("nil custom writers", func(t *testing.T) {
+		_, err := NewExec("echo", WithStdout(nil))
+		assert.Error(t, err)
+
+		_, err = NewExec("echo", WithStderr(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestExecuteWithCaptureLimit(t *testing.T) {
+	output := "0123456789abcdefghij"
+
+	testcases := []struct {
+		name      string
+		mode      CaptureMode
+		limit     int
+		want      string
+		truncated bool
+	}{
+		{
+			name:  "output within the limit",
+			mode:  CaptureHead,
+			limit: 64,
+			want:  output,
+		},
+		{
+			name:      "keeping the head",
+			mode:      CaptureHead,
+			limit:     4,
+			want:      "0123\n[... 16 bytes truncated ...]\n",
+			truncated: true,
+		},
+		{
+			name:      "keeping the tail",
+			mode:      CaptureTail,
+			limit:     4,
+			want:      "\n[... 16 bytes truncated ...]\nghij",
+			truncated: true,
+		},
+		{
+			name:      "keeping the head and the tail",
+			mode:      CaptureHeadTail,
+			limit:     8,
+			want:      "0123\n[... 12 bytes truncated ...]\nghij",
+			truncated: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// writing in small chunks to exercise the ring buffer
+			task, err := NewExec(`for c in $(echo "$OUTPUT" | fold -w 3); do printf "%s" "$c"; done`,
+				WithShell("/bin/sh"),
+				WithEnv("OUTPUT="+output),
+				WithCaptureLimit(tc.mode, tc.limit),
+			)
+			assert.NoError(t, err)
+
+			result := task.Execute()
+			assert.Equal(t, tc.want, result.Stdout)
+			assert.Equal(t, tc.truncated, result.Truncated)
+		})
+	}
+
+	t.Run("spilling the full output into a file", func(t *testing.T) {
+		dir := t.TempDir()
+
+		task, err := NewExec("printf", WithArgs(output),
+			WithCaptureLimit(CaptureTail, 4),
+			WithCaptureSpill(dir),
+		)
+		assert.NoError(t, err)
+
+		result := task.Execute()
+		assert.True(t, result.Truncated)
+		assert.Empty(t, result.StderrFile)
+
+		data, err := os.ReadFile(result.StdoutFile)
+		assert.NoError(t, err)
+		assert.Equal(t, output, string(data))
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1, "spill file of the untruncated stderr should be removed")
+	})
+
+	t.Run("invalid capture limit", func(t *testing.T) {
+		_, err := NewExec("echo", WithCaptureLimit(CaptureHead, 0))
+		assert.Error(t, err)
+
+		_, err = NewExec("echo", WithCaptureLimit(CaptureMode(0), 10))
+		assert.Error(t, err)
+	})
+}
+
+func TestExecuteWithEnvPolicy(t *testing.T) {
+	t.Setenv("EXEC_TEST_INHERITED", "inherited")
+	t.Setenv("EXEC_TEST_OTHER", "other")
+
+	t.Run("inheriting all environment variables", func(t *testing.T) {
+		result := MustExec("env", WithEnv("EXEC_TEST_OTHER=overridden")).Execute()
+		assert.Contains(t, result.Stdout, "EXEC_TEST_INHERITED=inherited\n")
+		assert.Contains(t, result.Stdout, "EXEC_TEST_OTHER=overridden\n")
+		assert.NotContains(t, result.Stdout, "EXEC_TEST_OTHER=other\n")
+		assert.Contains(t, result.Env, "EXEC_TEST_OTHER=overridden")
+	})
+
+	t.Run("inheriting the allowlist", func(t *testing.T) {
+		result := MustExec("/usr/bin/env", WithEnvPolicy(EnvInheritAllowlist, "EXEC_TEST_INHERITED")).Execute()
+		assert.Equal(t, "EXEC_TEST_INHERITED=inherited\n", result.Stdout)
+		assert.Equal(t, []string{"EXEC_TEST_INHERITED=inherited"}, result.Env)
+	})
+
+	t.Run("starting from a clean environment", func(t *testing.T) {
+		result := MustExec("/usr/bin/env",
+			WithEnvPolicy(EnvClean),
+			WithEnvMap(map[string]string{"B": "2", "A": "x=y"}),
+		).Execute()
+		assert.Equal(t, "A=x=y\nB=2\n", result.Stdout)
+		assert.Equal(t, []string{"A=x=y", "B=2"}, result.Env)
+	})
+
+	t.Run("values containing the separator", func(t *testing.T) {
+		result := MustExec(`printf "%s" "$OPTS"`,
+			WithShell("/bin/sh"),
+			WithEnv("OPTS=--set=a=b"),
+		).Execute()
+		assert.Equal(t, "--set=a=b", result.Stdout)
+	})
+
+	t.Run("invalid environment options", func(t *testing.T) {
+		_, err := NewExec("env", WithEnv("=value"))
+		assert.Error(t, err)
+
+		_, err = NewExec("env", WithEnvMap(map[string]string{"A=B": "c"}))
+		assert.Error(t, err)
+
+		_, err = NewExec("env", WithEnvPolicy(EnvInheritAllowlist))
+		assert.Error(t, err)
+
+		_, err = NewExec("env", WithEnvPolicy(EnvPolicy(10)))
+		assert.Error(t, err)
+	})
+}
+
+func TestCommandLine(t *testing.T) {
+	t.Run("arguments are split without a shell", func(t *testing.T) {
+		task := MustCommandLine(`printf '%s|' "a b" it\'s $HOME`, WithArgs("extra"))
+
+		result := task.Execute()
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "printf", result.Command)
+		assert.Equal(t, []string{"%s|", "a b", "it's", "$HOME", "extra"}, result.Args)
+		assert.Equal(t, "a b|it's|$HOME|extra|", result.Stdout)
+		assert.Equal(t, `printf '%s|' 'a b' 'it'\''s' '$HOME' extra`, task.String())
+	})
+
+	t.Run("invalid command lines", func(t *testing.T) {
+		_, err := NewCommandLine("   ")
+		assert.Error(t, err)
+
+		_, err = NewCommandLine(`echo "unterminated`)
+		assert.Error(t, err)
+
+		_, err = NewCommandLine("cat file | grep x")
+		assert.Error(t, err)
+
+		assert.Panics(t, func() { MustCommandLine("") })
 	})
 }
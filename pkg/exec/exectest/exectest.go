@@ -0,0 +1,286 @@
+// Package exectest provides a fake TaskExecutor for testing code built on top of pkg/exec
+// without running the real commands.
+//
+// The fake commands are served by re-executing the current (test) binary, which is
+// intercepted by this package before the tests are run, so it works without any
+// special TestMain.
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	envHelper   = "EXECTEST_HELPER_PROCESS"
+	envStdout   = "EXECTEST_STDOUT"
+	envStderr   = "EXECTEST_STDERR"
+	envExitCode = "EXECTEST_EXIT_CODE"
+	envDelay    = "EXECTEST_DELAY"
+
+	// unexpectedExitCode is the exit code of an unexpected command, similar to
+	// the shell exit code of a command not found
+	unexpectedExitCode = 127
+)
+
+func init() {
+	if os.Getenv(envHelper) != "1" {
+		return
+	}
+
+	if d, err := time.ParseDuration(os.Getenv(envDelay)); err == nil {
+		time.Sleep(d)
+	}
+
+	fmt.Fprint(os.Stdout, os.Getenv(envStdout))
+	fmt.Fprint(os.Stderr, os.Getenv(envStderr))
+
+	code, _ := strconv.Atoi(os.Getenv(envExitCode))
+	os.Exit(code)
+}
+
+// ArgsMatcher reports whether the command arguments are the expected ones
+type ArgsMatcher func(args []string) bool
+
+// Args matches the exact arguments
+func Args(want ...string) ArgsMatcher {
+	return func(args []string) bool {
+		if len(args) != len(want) {
+			return false
+		}
+
+		for i := range args {
+			if args[i] != want[i] {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// AnyArgs matches any arguments
+func AnyArgs() ArgsMatcher {
+	return func([]string) bool {
+		return true
+	}
+}
+
+// ArgsContain matches the arguments containing all of the given values, in any order
+func ArgsContain(values ...string) ArgsMatcher {
+	return func(args []string) bool {
+		for _, v := range values {
+			found := false
+			for _, a := range args {
+				if a == v {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// ArgsMatch matches the arguments, joined by a space, against the regular expression
+func ArgsMatch(rx *regexp.Regexp) ArgsMatcher {
+	return func(args []string) bool {
+		return rx.MatchString(strings.Join(args, " "))
+	}
+}
+
+// Call represent an invocation of the fake executor
+type Call struct {
+	Command string
+	Args    []string
+	Dir     string
+	Env     []string
+}
+
+// Expectation represent a scripted command along with its canned response
+type Expectation struct {
+	command string
+	args    ArgsMatcher
+
+	stdout   string
+	stderr   string
+	exitCode int
+	delay    time.Duration
+
+	times int
+	calls int
+}
+
+// Stdout set the standard output of the command
+func (e *Expectation) Stdout(stdout string) *Expectation {
+	e.stdout = stdout
+	return e
+}
+
+// Stderr set the standard error of the command
+func (e *Expectation) Stderr(stderr string) *Expectation {
+	e.stderr = stderr
+	return e
+}
+
+// ExitCode set the exit code of the command
+func (e *Expectation) ExitCode(code int) *Expectation {
+	e.exitCode = code
+	return e
+}
+
+// Delay set the duration the command runs before writing its output and exiting
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times set the exact number of expected invocations, by default
+// the command is expected to be invoked at least once
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Executor is a fake TaskExecutor, which serves the scripted commands and records
+// every invocation. An unexpected command fails the test and exits with code 127.
+type Executor struct {
+	t testing.TB
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []invocation
+}
+
+// invocation holds the command as it is requested, along with the command prepared from it
+type invocation struct {
+	command string
+	args    []string
+	cmd     *exec.Cmd
+}
+
+// NewExecutor returns a new Executor, which verifies every expectation
+// has been fulfilled once the test completes
+func NewExecutor(t testing.TB) *Executor {
+	f := &Executor{t: t}
+	t.Cleanup(f.assertExpectations)
+
+	return f
+}
+
+// Expect scripts the command with the matching arguments, the expectations
+// are matched in the order they are scripted
+func (f *Executor) Expect(command string, args ArgsMatcher) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if args == nil {
+		args = AnyArgs()
+	}
+
+	e := &Expectation{command: command, args: args}
+	f.expectations = append(f.expectations, e)
+
+	return e
+}
+
+// Exec satisfies the exec.TaskExecutor, to be used along with exec.WithExecutor(f.Exec)
+func (f *Executor) Exec(command string, args ...string) *exec.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var (
+		stdout, stderr string
+		exitCode       int
+		delay          time.Duration
+	)
+
+	if e := f.match(command, args); e != nil {
+		e.calls++
+		stdout, stderr, exitCode, delay = e.stdout, e.stderr, e.exitCode, e.delay
+	} else {
+		f.t.Errorf("exectest: unexpected command: %s %s", command, strings.Join(args, " "))
+		stderr, exitCode = fmt.Sprintf("exectest: unexpected command %q\n", command), unexpectedExitCode
+	}
+
+	cmd := exec.Command(os.Args[0], append([]string{command}, args...)...)
+	cmd.Env = []string{
+		envHelper + "=1",
+		envStdout + "=" + stdout,
+		envStderr + "=" + stderr,
+		envExitCode + "=" + strconv.Itoa(exitCode),
+		envDelay + "=" + delay.String(),
+	}
+
+	f.calls = append(f.calls, invocation{command: command, args: append([]string(nil), args...), cmd: cmd})
+	return cmd
+}
+
+func (f *Executor) match(command string, args []string) *Expectation {
+	for _, e := range f.expectations {
+		if e.command != command || !e.args(args) {
+			continue
+		}
+
+		if e.times > 0 && e.calls >= e.times {
+			continue
+		}
+
+		return e
+	}
+
+	return nil
+}
+
+// Calls returns every invocation of the executor, in order. The command and its arguments
+// are the requested ones, while the directory and the environment are read from the command
+// prepared by the task, hence Calls should be called once the tasks have completed.
+func (f *Executor) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]Call, 0, len(f.calls))
+	for _, c := range f.calls {
+		call := Call{
+			Command: c.command,
+			Args:    c.args,
+			Dir:     c.cmd.Dir,
+		}
+
+		for _, env := range c.cmd.Env {
+			if !strings.HasPrefix(env, "EXECTEST_") {
+				call.Env = append(call.Env, env)
+			}
+		}
+
+		calls = append(calls, call)
+	}
+
+	return calls
+}
+
+func (f *Executor) assertExpectations() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			f.t.Errorf("exectest: command %q expected to be invoked %d times, invoked %d times", e.command, e.times, e.calls)
+		case e.times == 0 && e.calls == 0:
+			f.t.Errorf("exectest: command %q expected to be invoked, but never invoked", e.command)
+		}
+	}
+}
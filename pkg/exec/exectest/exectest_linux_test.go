@@ -0,0 +1,32 @@
+//go:build linux
+
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/ardikabs/go-stdlib/pkg/exec/exectest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorWrappedCommand(t *testing.T) {
+	t.Run("calls keep the requested command", func(t *testing.T) {
+		fake := exectest.NewExecutor(t)
+		fake.Expect("kubectl", exectest.Args("get", "pods")).Stdout("pod-a\n")
+
+		result := exec.MustExec("kubectl",
+			exec.WithExecutor(fake.Exec),
+			exec.WithArgs("get", "pods"),
+			exec.WithRlimit(exec.RlimitNOFILE, 64, 64),
+			exec.WithUmask(0o022),
+		).Execute()
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "pod-a\n", result.Stdout)
+
+		calls := fake.Calls()
+		assert.Len(t, calls, 1)
+		assert.Equal(t, "kubectl", calls[0].Command)
+		assert.Equal(t, []string{"get", "pods"}, calls[0].Args)
+	})
+}
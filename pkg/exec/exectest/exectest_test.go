@@ -0,0 +1,94 @@
+package exectest_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/ardikabs/go-stdlib/pkg/exec/exectest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExecutor(t *testing.T) {
+	t.Run("serving the scripted commands", func(t *testing.T) {
+		fake := exectest.NewExecutor(t)
+		fake.Expect("kubectl", exectest.Args("get", "pods")).
+			Stdout("pod-a\npod-b\n").
+			Stderr("warning\n")
+		fake.Expect("kubectl", exectest.ArgsContain("delete")).
+			ExitCode(3).
+			Times(1)
+
+		result := exec.MustExec("kubectl",
+			exec.WithExecutor(fake.Exec),
+			exec.WithArgs("get", "pods"),
+			exec.WithDirectory("/tmp"),
+			exec.WithEnv("KUBECONFIG=/tmp/config"),
+		).Execute()
+		assert.Equal(t, 0, result.ExitCode)
+		assert.Equal(t, "pod-a\npod-b\n", result.Stdout)
+		assert.Equal(t, "warning\n", result.Stderr)
+
+		result = exec.MustExec("kubectl", exec.WithExecutor(fake.Exec), exec.WithArgs("delete", "pod", "pod-a")).Execute()
+		assert.Equal(t, 3, result.ExitCode)
+
+		calls := fake.Calls()
+		assert.Len(t, calls, 2)
+		assert.Equal(t, "kubectl", calls[0].Command)
+		assert.Equal(t, []string{"get", "pods"}, calls[0].Args)
+		assert.Equal(t, "/tmp", calls[0].Dir)
+		assert.Contains(t, calls[0].Env, "KUBECONFIG=/tmp/config")
+		assert.Equal(t, []string{"delete", "pod", "pod-a"}, calls[1].Args)
+	})
+
+	t.Run("delaying the command", func(t *testing.T) {
+		fake := exectest.NewExecutor(t)
+		fake.Expect("terraform", exectest.AnyArgs()).Delay(5 * time.Second)
+
+		result := exec.MustExec("terraform",
+			exec.WithExecutor(fake.Exec),
+			exec.WithTimeout(100*time.Millisecond),
+		).Execute()
+		assert.True(t, result.TimedOut)
+	})
+
+	t.Run("matching the arguments by regular expression", func(t *testing.T) {
+		fake := exectest.NewExecutor(t)
+		fake.Expect("helm", exectest.ArgsMatch(regexp.MustCompile(`^template \S+$`))).Stdout("kind: Pod\n")
+
+		result := exec.MustExec("helm", exec.WithExecutor(fake.Exec), exec.WithArgs("template", "chart")).Execute()
+		assert.Equal(t, "kind: Pod\n", result.Stdout)
+	})
+
+	t.Run("failing on unexpected and missing commands", func(t *testing.T) {
+		rec := &recorder{TB: t}
+
+		var fake *exectest.Executor
+		t.Run("scripted", func(t *testing.T) {
+			rec.TB = t
+			fake = exectest.NewExecutor(rec)
+			fake.Expect("git", exectest.Args("fetch")).Times(2)
+
+			result := exec.MustExec("git", exec.WithExecutor(fake.Exec), exec.WithArgs("push")).Execute()
+			assert.Equal(t, 127, result.ExitCode)
+			assert.Len(t, rec.errors, 1)
+
+			_ = exec.MustExec("git", exec.WithExecutor(fake.Exec), exec.WithArgs("fetch")).Execute()
+		})
+
+		assert.Len(t, rec.errors, 2)
+		assert.Contains(t, rec.errors[1], "expected to be invoked 2 times, invoked 1 times")
+	})
+}
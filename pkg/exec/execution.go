@@ -0,0 +1,370 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// execution holds the state of a single run of a task
+type execution struct {
+	task *Task
+	cmd  *exec.Cmd
+	log  zerolog.Logger
+
+	command string
+	args    []string
+
+	stdout *capture
+	stderr *capture
+	// stdoutWriters holds the writers receiving the stdout besides the capture
+	stdoutWriters []io.Writer
+
+	flushers []flusher
+	lineMu   sync.Mutex
+	decoder  *jsonLinesDecoder
+	pty      *pty
+	idle     *idleWatchdog
+	// guard kills the process group of the command when the current process dies
+	guard *groupGuard
+	// owned holds the files closed once the command exits
+	owned []*os.File
+
+	// hooked reports whether the start hooks have been called
+	hooked bool
+	// held reports whether the command is held until its resource limits are set
+	held bool
+	// idleKilled reports whether the command has been terminated by the idle watchdog
+	idleKilled bool
+
+	startedAt time.Time
+
+	waitc chan error
+}
+
+// newExecution builds the command of the task, ready to be started
+func (t *Task) newExecution() (*execution, error) {
+	command, args := t.resolve()
+
+	e := &execution{
+		task:    t,
+		command: command,
+		args:    args,
+		stdout:  newCapture(t.captureMode, t.captureLimit),
+		stderr:  newCapture(t.captureMode, t.captureLimit),
+		waitc:   make(chan error, 1),
+	}
+
+	if t.idleTimeout > 0 {
+		e.idle = newIdleWatchdog(t.idleTimeout)
+	}
+
+	e.log = t.baseLogger().With().
+		Str("dir", t.cwd).
+		Str("cmd", t.redact.text(t.command)).
+		Str("args", strings.Join(t.redact.args(t.args), " ")).
+		Logger()
+
+	if t.shellMode {
+		e.log = e.log.With().Str("shell", command).Logger()
+	}
+
+	cmd := t.exec(command, args...)
+	cmd.Dir = t.cwd
+	cmd.Stdin = t.stdin
+
+	applyProcAttr(cmd, t.procAttr)
+	if t.processGroup {
+		setProcessGroup(cmd)
+	}
+
+	e.stdoutWriters = e.writers(StreamStdout, os.Stdout, t.stdout)
+	cmd.Stdout = output(e.stdout, e.stdoutWriters)
+	cmd.Stderr = output(e.stderr, e.writers(StreamStderr, os.Stderr, t.stderr))
+
+	if len(t.env) > 0 {
+		e.log = e.log.With().
+			Str("env", strings.Join(t.redact.env(t.env), ",")).
+			Logger()
+	}
+
+	cmd.Env = t.environ(cmd.Env)
+
+	e.cmd = cmd
+
+	// the umask is set, and the command is held until its limits are set, by a shell wrapper
+	e.held = wrapCommand(cmd, t.procAttr, len(t.rlimits) > 0)
+
+	if t.pty != nil {
+		var err error
+		if e.pty, err = openPTY(cmd, *t.pty); err != nil {
+			return e, err
+		}
+		e.pty.raw = t.streamIO
+	}
+
+	if t.spill {
+		var err error
+		if e.stdout.spill, err = os.CreateTemp(t.spillDir, "exec-stdout-*"); err != nil {
+			return e, err
+		}
+
+		if e.stderr.spill, err = os.CreateTemp(t.spillDir, "exec-stderr-*"); err != nil {
+			return e, err
+		}
+	}
+
+	return e, nil
+}
+
+// start starts the command without waiting for it to complete
+func (e *execution) start(ctx context.Context) error {
+	// the hooks are not called for a task which is not going to be started
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.hooked = true
+	for _, hook := range e.task.onStart {
+		hook(ctx, e.task)
+	}
+
+	if e.task.debug {
+		e.log.Debug().Msg("executing the command")
+	}
+
+	startedAt := time.Now()
+	if err := e.cmd.Start(); err != nil {
+		return err
+	}
+
+	if e.task.processGroup {
+		var err error
+		if e.guard, err = startGroupGuard(e.cmd.Process.Pid); err != nil {
+			_ = e.task.signal(e.cmd, syscall.SIGKILL)
+			_ = e.cmd.Wait()
+			return fmt.Errorf("could not guard the process group: %w", err)
+		}
+	}
+
+	if err := applyRlimits(e.cmd.Process.Pid, e.task.rlimits, e.held); err != nil {
+		_ = e.task.signal(e.cmd, syscall.SIGKILL)
+		_ = e.cmd.Wait()
+		e.guard.release()
+		return fmt.Errorf("could not set the resource limits: %w", err)
+	}
+	e.startedAt = startedAt
+
+	if e.pty != nil {
+		e.pty.start()
+	}
+
+	if e.idle != nil {
+		e.idle.start()
+	}
+
+	go func() {
+		err := e.cmd.Wait()
+		e.guard.release()
+		closeFiles(e.owned)
+		if e.idle != nil {
+			close(e.idle.stop)
+		}
+		if e.pty != nil {
+			e.pty.wait()
+		}
+		e.waitc <- err
+	}()
+
+	return nil
+}
+
+// wait waits for the started command to exit. When the context is done, or the idle
+// watchdog fires, before the command exits, the command is terminated.
+func (e *execution) wait(ctx context.Context) Result {
+	var idlec <-chan struct{}
+	if e.idle != nil {
+		idlec = e.idle.fired
+	}
+
+	select {
+	case err := <-e.waitc:
+		return e.result(ctx, false, err)
+	case <-ctx.Done():
+		return e.result(ctx, true, e.task.terminate(e.cmd, e.waitc))
+	case <-idlec:
+		if e.task.debug {
+			e.log.Debug().Dur("idle_timeout", e.task.idleTimeout).Msg("the command has been silent for too long")
+		}
+
+		e.idleKilled = true
+		return e.result(ctx, false, e.task.terminate(e.cmd, e.waitc))
+	}
+}
+
+// result builds the result of the execution from the command error
+func (e *execution) result(ctx context.Context, interrupted bool, err error) (result Result) {
+	for _, f := range e.flushers {
+		_ = f.Flush()
+	}
+
+	// the pseudo-terminal is left open when the command could not be started
+	if e.pty != nil {
+		e.pty.closeTTY()
+		e.pty.master.Close()
+	}
+
+	// a context done before the command is started also counts as an interruption
+	if err != nil && err == ctx.Err() {
+		interrupted = true
+	}
+
+	if interrupted {
+		result.TimedOut = ctx.Err() == context.DeadlineExceeded
+		result.Canceled = !result.TimedOut
+
+		if e.task.debug {
+			e.log.Debug().Err(ctx.Err()).Msg("the command has been interrupted")
+		}
+	}
+
+	if err != nil && e.cmd.Process == nil {
+		err = classifyStartError(e.cmd, e.task.procAttr.chroot, err)
+	}
+
+	result.err = err
+	result.IdleKilled = e.idleKilled
+	if e.decoder != nil {
+		result.decodeErr = e.decoder.err
+	}
+	result.Command = e.command
+	result.Args = e.args
+	result.safeArgs = e.task.redact.args(e.args)
+	result.Env = e.cmd.Env
+	result.Stdout = e.captured(e.stdout)
+	result.Stderr = e.captured(e.stderr)
+	if e.task.script {
+		result.FailedLine, result.Stderr = scriptFailedLine(result.Stderr)
+	}
+	result.Truncated = e.stdout.Truncated() || e.stderr.Truncated()
+	result.StdoutFile = e.stdout.close()
+	result.StderrFile = e.stderr.close()
+
+	if !e.startedAt.IsZero() {
+		result.StartedAt = e.startedAt
+		result.Duration = time.Since(e.startedAt)
+	}
+
+	if state := e.cmd.ProcessState; state != nil {
+		result.UserTime = state.UserTime()
+		result.SystemTime = state.SystemTime()
+		result.MaxRSS = maxRSS(state)
+
+		if ws, ok := state.Sys().(waitStatus); ok && ws.Signaled() {
+			result.Signal = ws.Signal()
+		}
+
+		result.LimitExceeded = exceededRlimit(e.task.rlimits, result)
+	}
+
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitError.ExitCode()
+		} else {
+			// This will happen (in OSX) if `t.Command` is not available in $PATH,
+			// in this situation, exit code could not be get, and stderr will be
+			// empty string very likely, so we use the default fail code, and format err
+			// to string and set to stderr
+
+			if e.task.debug {
+				e.log.Debug().Msg("could not get exit code for failed command, return with default failed exit code")
+			}
+
+			result.ExitCode = defaultFailedCode
+			if result.Stderr == "" {
+				result.Stderr = err.Error()
+			}
+		}
+	}
+
+	if e.hooked {
+		for _, hook := range e.task.onFinish {
+			hook(ctx, e.task, result)
+		}
+	}
+
+	return
+}
+
+// captured returns the captured output, redacted when the output redaction is enabled
+func (e *execution) captured(c *capture) string {
+	if e.task.redactOutput {
+		return e.task.redact.text(c.String())
+	}
+
+	return c.String()
+}
+
+// output combines the in-memory capture with the other writers of the stream, if any
+func output(capture io.Writer, writers []io.Writer) io.Writer {
+	if len(writers) == 0 {
+		return capture
+	}
+
+	return io.MultiWriter(append([]io.Writer{capture}, writers...)...)
+}
+
+// writers returns the writers receiving the output of the stream besides the capture:
+// the stream passthrough, when enabled, the idle watchdog, the user provided writer,
+// the line handler and the JSON lines decoder, if any
+func (e *execution) writers(stream string, std io.Writer, custom io.Writer) []io.Writer {
+	t := e.task
+
+	writers := []io.Writer{}
+	if t.streamIO {
+		if t.prefix != "" {
+			pw := NewPrefixWriter(std, t.prefix, t.prefixColor)
+			e.flushers = append(e.flushers, pw)
+			std = pw
+		}
+
+		writers = append(writers, std)
+	}
+
+	if e.idle != nil {
+		writers = append(writers, e.idle)
+	}
+	if custom != nil {
+		writers = append(writers, custom)
+	}
+
+	if t.lineHandler != nil {
+		lw := newLineWriter(func(line string) error {
+			// the stdout and stderr are copied concurrently
+			e.lineMu.Lock()
+			defer e.lineMu.Unlock()
+
+			t.lineHandler(stream, line)
+			return nil
+		})
+		e.flushers = append(e.flushers, lw)
+		writers = append(writers, lw)
+	}
+
+	if stream == StreamStdout && t.jsonLines != nil {
+		e.decoder = &jsonLinesDecoder{decode: t.jsonLines}
+		lw := newLineWriter(e.decoder.line)
+		e.flushers = append(e.flushers, lw)
+		writers = append(writers, lw)
+	}
+
+	return writers
+}
@@ -0,0 +1,390 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultExpectTimeout = 10 * time.Second
+
+var (
+	// ErrExpectTimeout is returned when none of the expected patterns matched the output in time
+	ErrExpectTimeout = errors.New("expect timed out")
+
+	// ErrExpectEOF is returned when the process completed before any of the expected patterns matched
+	ErrExpectEOF = errors.New("process completed")
+)
+
+// Pattern is matched against the output of an interactive session
+type Pattern interface {
+	// find returns the index pairs of the leftmost match and its submatches, nil if no match
+	find(s string) []int
+	String() string
+}
+
+type literalPattern string
+
+func (p literalPattern) find(s string) []int {
+	i := strings.Index(s, string(p))
+	if i < 0 {
+		return nil
+	}
+
+	return []int{i, i + len(p)}
+}
+
+func (p literalPattern) String() string {
+	return fmt.Sprintf("%q", string(p))
+}
+
+type regexpPattern struct {
+	rx *regexp.Regexp
+}
+
+func (p regexpPattern) find(s string) []int {
+	return p.rx.FindStringSubmatchIndex(s)
+}
+
+func (p regexpPattern) String() string {
+	return fmt.Sprintf("/%s/", p.rx)
+}
+
+// LiteralPattern returns a Pattern matching the given text
+func LiteralPattern(text string) Pattern {
+	return literalPattern(text)
+}
+
+// RegexpPattern returns a Pattern matching the given regular expression
+func RegexpPattern(rx *regexp.Regexp) Pattern {
+	return regexpPattern{rx: rx}
+}
+
+// Match represent the output matched by Session.Expect
+type Match struct {
+	// Pattern is the index of the matched pattern
+	Pattern int
+	// Output is the output consumed by the match, from the end of the previous match
+	// up to the end of this match
+	Output string
+	// Groups holds the matched text followed by the submatches of a regexp pattern
+	Groups []string
+}
+
+// Step represent a step of an interactive session, see Session.Run
+type Step struct {
+	// Expect is the pattern to wait for, the step does not wait when nil
+	Expect Pattern
+	// Send is the line sent once the pattern matched, nothing is sent when empty
+	Send string
+	// Secret hides the sent line from the transcript
+	Secret bool
+	// Timeout is the maximum duration to wait for the pattern, by default the session one
+	Timeout time.Duration
+}
+
+// Session is an expect-like interactive session with a running task: the output,
+// stdout and stderr merged, is matched against patterns and the responses are sent
+// to the stdin of the task
+type Session struct {
+	p     *Process
+	stdin io.WriteCloser
+
+	timeout time.Duration
+
+	mu         sync.Mutex
+	buf        []byte
+	transcript bytes.Buffer
+	redact     redactor
+
+	notify chan struct{}
+}
+
+// SessionOption represent the interactive session option
+type SessionOption func(*Session) error
+
+// WithExpectTimeout set the default maximum duration to wait for an expected pattern
+func WithExpectTimeout(d time.Duration) SessionOption {
+	return func(s *Session) error {
+		if d <= 0 {
+			return fmt.Errorf("expect timeout must be positive")
+		}
+
+		s.timeout = d
+		return nil
+	}
+}
+
+// NewSession starts the task in an interactive session. The stdin of the task is
+// replaced by the session, and its output is still captured into the Result.
+// The process is terminated when the context is done, or when the task timeout is exceeded.
+func NewSession(ctx context.Context, t *Task, opts ...SessionOption) (*Session, error) {
+	if t == nil {
+		return nil, fmt.Errorf("session task couldn't be nil")
+	}
+
+	s := &Session{
+		timeout: defaultExpectTimeout,
+		redact:  t.redact,
+		notify:  make(chan struct{}, 1),
+	}
+	s.redact.secrets = append([]string(nil), t.redact.secrets...)
+
+	for _, o := range opts {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// the task is copied, so that it is left untouched and can be reused
+	tc := *t
+	tc.stdin = r
+	tc.stdout = s.tap(t.stdout)
+	tc.stderr = s.tap(t.stderr)
+
+	p, err := tc.StartContext(ctx)
+	if err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+
+	// with a pty, the input is copied from the pipe until the process completes
+	if tc.pty == nil {
+		r.Close()
+	} else {
+		go func() {
+			<-p.Done()
+			r.Close()
+		}()
+	}
+
+	s.p = p
+	s.stdin = w
+	return s, nil
+}
+
+// tap returns a writer feeding the session, followed by the given writer, if any
+func (s *Session) tap(w io.Writer) io.Writer {
+	tw := writerFunc(func(p []byte) (int, error) {
+		s.mu.Lock()
+		s.buf = append(s.buf, p...)
+		s.transcript.Write(p)
+		s.mu.Unlock()
+
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+
+		return len(p), nil
+	})
+
+	if w == nil {
+		return tw
+	}
+
+	return io.MultiWriter(tw, w)
+}
+
+// Expect waits until the output matches one of the patterns, the matched output is consumed.
+// A zero timeout means the session timeout.
+func (s *Session) Expect(timeout time.Duration, patterns ...Pattern) (Match, error) {
+	if len(patterns) == 0 {
+		return Match{}, fmt.Errorf("expect requires at least one pattern")
+	}
+
+	if timeout <= 0 {
+		timeout = s.timeout
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		// the output is complete once the process is done
+		done := false
+		select {
+		case <-s.p.Done():
+			done = true
+		default:
+		}
+
+		if m, ok := s.match(patterns); ok {
+			return m, nil
+		}
+
+		if done {
+			return Match{}, fmt.Errorf("%w before matching %s, last output: %q", ErrExpectEOF, patternList(patterns), s.pending())
+		}
+
+		select {
+		case <-s.notify:
+		case <-s.p.Done():
+		case <-timer.C:
+			return Match{}, fmt.Errorf("%w after %s waiting for %s, last output: %q", ErrExpectTimeout, timeout, patternList(patterns), s.pending())
+		}
+	}
+}
+
+// match consumes the output up to the leftmost match of the patterns, if any
+func (s *Session) match(patterns []Pattern) (Match, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := string(s.buf)
+
+	var (
+		best  []int
+		index = -1
+	)
+	for i, p := range patterns {
+		if loc := p.find(out); loc != nil && (best == nil || loc[0] < best[0]) {
+			best, index = loc, i
+		}
+	}
+
+	if best == nil {
+		return Match{}, false
+	}
+
+	m := Match{Pattern: index, Output: out[:best[1]]}
+	for g := 0; g < len(best); g += 2 {
+		if best[g] < 0 {
+			m.Groups = append(m.Groups, "")
+			continue
+		}
+
+		m.Groups = append(m.Groups, out[best[g]:best[g+1]])
+	}
+
+	s.buf = s.buf[best[1]:]
+	return m, true
+}
+
+// pending returns the tail of the unmatched output, redacted
+func (s *Session) pending() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.redact.text(string(s.buf))
+	return snippet(out, len(out))
+}
+
+// Send writes the text to the stdin of the task
+func (s *Session) Send(text string) error {
+	return s.send(text, false)
+}
+
+// SendLine writes the line, followed by a newline, to the stdin of the task
+func (s *Session) SendLine(line string) error {
+	return s.send(line+"\n", false)
+}
+
+// SendSecret writes the secret to the stdin of the task, the secret is redacted
+// from the transcript, including when it is echoed back by the task
+func (s *Session) SendSecret(secret string) error {
+	return s.send(secret, true)
+}
+
+// SendSecretLine is like SendSecret but followed by a newline
+func (s *Session) SendSecretLine(secret string) error {
+	return s.send(secret+"\n", true)
+}
+
+func (s *Session) send(text string, secret bool) error {
+	s.mu.Lock()
+	if secret {
+		if v := strings.TrimRight(text, "\r\n"); v != "" {
+			s.redact.secrets = append(s.redact.secrets, v)
+		}
+	}
+	s.transcript.WriteString(text)
+	s.mu.Unlock()
+
+	if _, err := io.WriteString(s.stdin, text); err != nil {
+		return fmt.Errorf("could not send to the process: %w", err)
+	}
+
+	return nil
+}
+
+// Run runs the steps in order, stopping at the first error
+func (s *Session) Run(steps ...Step) error {
+	for i, step := range steps {
+		if step.Expect != nil {
+			if _, err := s.Expect(step.Timeout, step.Expect); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+
+		if step.Send == "" {
+			continue
+		}
+
+		send := s.SendLine
+		if step.Secret {
+			send = s.SendSecretLine
+		}
+
+		if err := send(step.Send); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Transcript returns the output received and the input sent so far, in order,
+// with the secrets redacted
+func (s *Session) Transcript() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.redact.text(s.transcript.String())
+}
+
+// Process returns the running process of the session
+func (s *Session) Process() *Process {
+	return s.p
+}
+
+// Close closes the stdin of the task, signaling the end of the input
+func (s *Session) Close() error {
+	return s.stdin.Close()
+}
+
+// Wait closes the stdin of the task, then waits for the process to complete
+// and returns its result
+func (s *Session) Wait() Result {
+	_ = s.stdin.Close()
+	return s.p.Wait()
+}
+
+func patternList(patterns []Pattern) string {
+	names := make([]string, len(patterns))
+	for i, p := range patterns {
+		names[i] = p.String()
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// writerFunc is an adapter allowing the use of a function as an io.Writer
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,98 @@
+package exec_test
+
+import (
+	"context"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+const loginScript = `
+printf 'Continue? [y/N] '
+read answer
+[ "$answer" = y ] || exit 2
+printf 'Password: ' >&2
+read password
+echo "logged in with $password"
+`
+
+func TestSession(t *testing.T) {
+	t.Run("expect and send", func(t *testing.T) {
+		s, err := NewSession(context.Background(), MustExec(loginScript, WithShell("/bin/sh")))
+		assert.NoError(t, err)
+
+		m, err := s.Expect(0, LiteralPattern("[y/N]"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Continue? [y/N]", m.Output)
+		assert.NoError(t, s.SendLine("y"))
+
+		m, err = s.Expect(time.Second, LiteralPattern("Username:"), RegexpPattern(regexp.MustCompile(`(Pass)word:`)))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, m.Pattern)
+		assert.Equal(t, []string{"Password:", "Pass"}, m.Groups)
+		assert.NoError(t, s.SendSecretLine("hunter2"))
+
+		_, err = s.Expect(0, LiteralPattern("logged in"))
+		assert.NoError(t, err)
+
+		result := s.Wait()
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "Continue? [y/N] logged in with hunter2\n", result.Stdout)
+		assert.Equal(t, "Continue? [y/N] y\nPassword: REDACTED\nlogged in with REDACTED\n", s.Transcript())
+	})
+
+	t.Run("run the steps", func(t *testing.T) {
+		s, err := NewSession(context.Background(), MustExec(loginScript, WithShell("/bin/sh")))
+		assert.NoError(t, err)
+
+		err = s.Run(
+			Step{Expect: LiteralPattern("[y/N]"), Send: "y"},
+			Step{Expect: LiteralPattern("Password:"), Send: "s3cret", Secret: true, Timeout: time.Second},
+			Step{Expect: LiteralPattern("logged in")},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, s.Wait().ExitCode)
+		assert.NotContains(t, s.Transcript(), "s3cret")
+	})
+
+	t.Run("expect timeout", func(t *testing.T) {
+		s, err := NewSession(context.Background(), MustExec("echo waiting; exec sleep 5", WithShell("/bin/sh")),
+			WithExpectTimeout(100*time.Millisecond))
+		assert.NoError(t, err)
+
+		_, err = s.Expect(0, LiteralPattern("ready"))
+		assert.ErrorIs(t, err, ErrExpectTimeout)
+		assert.Contains(t, err.Error(), "waiting")
+
+		assert.NoError(t, s.Process().Signal(syscall.SIGKILL))
+		s.Wait()
+	})
+
+	t.Run("process completed before matching", func(t *testing.T) {
+		s, err := NewSession(context.Background(), MustExec(loginScript, WithShell("/bin/sh")))
+		assert.NoError(t, err)
+
+		err = s.Run(
+			Step{Expect: LiteralPattern("[y/N]"), Send: "n"},
+			Step{Expect: LiteralPattern("Password:")},
+		)
+		assert.ErrorIs(t, err, ErrExpectEOF)
+		assert.Contains(t, err.Error(), "step 2")
+		assert.Equal(t, 2, s.Wait().ExitCode)
+	})
+
+	t.Run("invalid sessions", func(t *testing.T) {
+		_, err := NewSession(context.Background(), nil)
+		assert.Error(t, err)
+
+		_, err = NewSession(context.Background(), MustExec("cat"), WithExpectTimeout(0))
+		assert.Error(t, err)
+
+		_, err = NewSession(context.Background(), MustExec("command-does-not-exist"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,313 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NodeStatus represent the final status of a graph node
+type NodeStatus string
+
+const (
+	// NodeSucceeded means the task completed successfully
+	NodeSucceeded NodeStatus = "succeeded"
+	// NodeFailed means the task failed
+	NodeFailed NodeStatus = "failed"
+	// NodeCanceled means the task was interrupted, or not started, because the graph was canceled
+	NodeCanceled NodeStatus = "canceled"
+	// NodeSkipped means the task was not started because one of its dependencies did not succeed
+	NodeSkipped NodeStatus = "skipped"
+)
+
+// Graph runs named tasks following their dependencies, the tasks which
+// do not depend on each other are run in parallel
+type Graph struct {
+	runner *Runner
+
+	nodes map[string]*graphNode
+	names []string
+}
+
+type graphNode struct {
+	name       string
+	task       *Task
+	deps       []string
+	dependents []string
+}
+
+// NodeReport represent the execution report of a graph node
+type NodeReport struct {
+	Name      string
+	Status    NodeStatus
+	Result    Result
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// Report represent the execution report of the graph,
+// the nodes are reported in the order they are added to the graph
+type Report struct {
+	Nodes    []NodeReport
+	Duration time.Duration
+}
+
+// NodeError describes a graph node that failed
+type NodeError struct {
+	Name   string
+	Result Result
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %q (%s) failed with exit code %d", e.Name, e.Result.Command, e.Result.ExitCode)
+}
+
+// Unwrap returns the error of the node task result
+func (e *NodeError) Unwrap() error {
+	return e.Result.Err()
+}
+
+// NewGraph returns a new Graph following with error, the runner options
+// define the graph concurrency and whether it stops on the first failure
+func NewGraph(opts ...RunnerOption) (*Graph, error) {
+	r, err := NewRunner(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Graph{
+		runner: r,
+		nodes:  make(map[string]*graphNode),
+	}, nil
+}
+
+// Add adds a named task to the graph, which is run once all of its dependencies succeeded.
+// The dependencies may be added to the graph later.
+func (g *Graph) Add(name string, task *Task, deps ...string) error {
+	if name == "" {
+		return fmt.Errorf("node name couldn't be empty")
+	}
+
+	if task == nil {
+		return fmt.Errorf("node %q task couldn't be nil", name)
+	}
+
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("node %q already exists", name)
+	}
+
+	g.nodes[name] = &graphNode{name: name, task: task, deps: deps}
+	g.names = append(g.names, name)
+	return nil
+}
+
+// Validate checks every dependency exists and the graph has no cycle
+func (g *Graph) Validate() error {
+	for _, name := range g.names {
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("node %q depends on unknown node %q", name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(g.nodes))
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i := range path {
+				if path[i] == name {
+					return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, name := range g.names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run runs the graph and waits for every node to complete
+func (g *Graph) Run() (*Report, error) {
+	return g.RunContext(context.Background())
+}
+
+// RunContext runs the graph and waits for every node to complete, or until the context
+// is done. The returned error is either the validation error, in which case nothing is run,
+// or the aggregated *NodeError of every failed node.
+func (g *Graph) RunContext(ctx context.Context) (*Report, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type completion struct {
+		name   string
+		report NodeReport
+	}
+
+	var (
+		start     = time.Now()
+		reports   = make(map[string]NodeReport, len(g.nodes))
+		remaining = make(map[string]int, len(g.nodes))
+		blocked   = make(map[string]bool, len(g.nodes))
+		ready     []string
+		running   int
+		donec     = make(chan completion)
+	)
+
+	for _, name := range g.names {
+		node := g.nodes[name]
+		node.dependents = nil
+	}
+
+	for _, name := range g.names {
+		node := g.nodes[name]
+		remaining[name] = len(node.deps)
+		for _, dep := range node.deps {
+			g.nodes[dep].dependents = append(g.nodes[dep].dependents, name)
+		}
+
+		if len(node.deps) == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	// finish records the node report and releases its dependents
+	var finish func(report NodeReport)
+	finish = func(report NodeReport) {
+		reports[report.Name] = report
+
+		if report.Status == NodeFailed && g.runner.failFast {
+			cancel()
+		}
+
+		for _, dependent := range g.nodes[report.Name].dependents {
+			if report.Status != NodeSucceeded {
+				blocked[dependent] = true
+			}
+
+			remaining[dependent]--
+			if remaining[dependent] > 0 {
+				continue
+			}
+
+			if blocked[dependent] {
+				finish(NodeReport{Name: dependent, Status: NodeSkipped})
+				continue
+			}
+
+			ready = append(ready, dependent)
+		}
+	}
+
+	for len(reports) < len(g.nodes) {
+		for len(ready) > 0 && (running < g.runner.concurrency || ctx.Err() != nil) {
+			name := ready[0]
+			ready = ready[1:]
+
+			if ctx.Err() != nil {
+				finish(NodeReport{Name: name, Status: NodeCanceled})
+				continue
+			}
+
+			running++
+			go func(node *graphNode) {
+				report := NodeReport{Name: node.name, StartedAt: time.Now()}
+				report.Result = g.runner.execute(ctx, node.task)
+				report.Duration = time.Since(report.StartedAt)
+
+				switch {
+				case !failedResult(report.Result):
+					report.Status = NodeSucceeded
+				case report.Result.Canceled:
+					report.Status = NodeCanceled
+				default:
+					report.Status = NodeFailed
+				}
+
+				donec <- completion{name: node.name, report: report}
+			}(g.nodes[name])
+		}
+
+		if running == 0 {
+			continue
+		}
+
+		c := <-donec
+		running--
+		finish(c.report)
+	}
+
+	report := &Report{Duration: time.Since(start)}
+
+	var errs []error
+	for _, name := range g.names {
+		r := reports[name]
+		report.Nodes = append(report.Nodes, r)
+
+		if r.Status == NodeFailed {
+			errs = append(errs, &NodeError{Name: name, Result: r.Result})
+		}
+	}
+
+	if err := parent.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// String renders the report as a summary table
+func (r *Report) String() string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATUS\tEXIT CODE\tDURATION")
+	for _, n := range r.Nodes {
+		code := "-"
+		if n.Status != NodeSkipped && !n.StartedAt.IsZero() {
+			code = fmt.Sprint(n.Result.ExitCode)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.Name, n.Status, code, n.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(w, "TOTAL\t\t\t%s\n", r.Duration.Round(time.Millisecond))
+	w.Flush()
+
+	return sb.String()
+}
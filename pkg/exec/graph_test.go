@@ -0,0 +1,115 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphValidate(t *testing.T) {
+	t.Run("invalid nodes", func(t *testing.T) {
+		g, err := NewGraph()
+		assert.NoError(t, err)
+
+		assert.Error(t, g.Add("", MustExec("true")))
+		assert.Error(t, g.Add("nil", nil))
+		assert.NoError(t, g.Add("a", MustExec("true")))
+		assert.Error(t, g.Add("a", MustExec("true")))
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		g, err := NewGraph()
+		assert.NoError(t, err)
+
+		assert.NoError(t, g.Add("a", MustExec("true"), "missing"))
+		assert.ErrorContains(t, g.Validate(), `unknown node "missing"`)
+	})
+
+	t.Run("dependency cycle", func(t *testing.T) {
+		g, err := NewGraph()
+		assert.NoError(t, err)
+
+		assert.NoError(t, g.Add("a", MustExec("true"), "c"))
+		assert.NoError(t, g.Add("b", MustExec("true"), "a"))
+		assert.NoError(t, g.Add("c", MustExec("true"), "b"))
+
+		report, err := g.Run()
+		assert.Nil(t, report)
+		assert.ErrorContains(t, err, "a -> c -> b -> a")
+	})
+}
+
+func TestGraphRun(t *testing.T) {
+	t.Run("running the nodes following the dependencies", func(t *testing.T) {
+		g, err := NewGraph(WithConcurrency(4))
+		assert.NoError(t, err)
+
+		assert.NoError(t, g.Add("package", MustExec("echo", WithArgs("package")), "compile", "test"))
+		assert.NoError(t, g.Add("compile", MustExec("sleep", WithArgs("0.2")), "generate"))
+		assert.NoError(t, g.Add("test", MustExec("sleep", WithArgs("0.2")), "generate"))
+		assert.NoError(t, g.Add("generate", MustExec("echo", WithArgs("generate"))))
+
+		start := time.Now()
+		report, err := g.Run()
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 350*time.Millisecond, "compile and test should run in parallel")
+
+		assert.Len(t, report.Nodes, 4)
+		byName := map[string]NodeReport{}
+		for _, n := range report.Nodes {
+			assert.Equal(t, NodeSucceeded, n.Status)
+			byName[n.Name] = n
+		}
+
+		assert.False(t, byName["compile"].StartedAt.Before(byName["generate"].StartedAt.Add(byName["generate"].Duration)))
+		assert.False(t, byName["package"].StartedAt.Before(byName["test"].StartedAt.Add(byName["test"].Duration)))
+		assert.Contains(t, report.String(), "package")
+	})
+
+	t.Run("skipping the dependents of a failed node", func(t *testing.T) {
+		g, err := NewGraph()
+		assert.NoError(t, err)
+
+		assert.NoError(t, g.Add("generate", MustExec("exit 1", WithShell("/bin/sh"))))
+		assert.NoError(t, g.Add("compile", MustExec("true"), "generate"))
+		assert.NoError(t, g.Add("package", MustExec("true"), "compile"))
+		assert.NoError(t, g.Add("lint", MustExec("true")))
+
+		report, err := g.Run()
+		assert.Error(t, err)
+
+		var nodeErr *NodeError
+		assert.True(t, errors.As(err, &nodeErr))
+		assert.Equal(t, "generate", nodeErr.Name)
+
+		statuses := map[string]NodeStatus{}
+		for _, n := range report.Nodes {
+			statuses[n.Name] = n.Status
+		}
+
+		assert.Equal(t, map[string]NodeStatus{
+			"generate": NodeFailed,
+			"compile":  NodeSkipped,
+			"package":  NodeSkipped,
+			"lint":     NodeSucceeded,
+		}, statuses)
+	})
+
+	t.Run("fail fast cancels the running nodes", func(t *testing.T) {
+		g, err := NewGraph(WithConcurrency(2), WithFailFast())
+		assert.NoError(t, err)
+
+		assert.NoError(t, g.Add("slow", MustExec("sleep", WithArgs("5"))))
+		assert.NoError(t, g.Add("broken", MustExec("exit 1", WithShell("/bin/sh"))))
+
+		start := time.Now()
+		report, err := g.Run()
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, NodeCanceled, report.Nodes[0].Status)
+		assert.Equal(t, NodeFailed, report.Nodes[1].Status)
+	})
+}
@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey struct{}
+
+func TestHooks(t *testing.T) {
+	t.Run("calling the hooks in order", func(t *testing.T) {
+		var events []string
+
+		tc := MustExec("echo", WithArgs("hello"),
+			OnStart(func(ctx context.Context, task *Task) {
+				events = append(events, "start:"+ctx.Value(ctxKey{}).(string)+":"+task.String())
+			}),
+			OnStart(func(ctx context.Context, task *Task) {
+				events = append(events, "start-2")
+			}),
+			OnFinish(func(ctx context.Context, task *Task, r Result) {
+				events = append(events, "finish:"+r.Stdout)
+			}),
+		)
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "req-1")
+		tc.ExecuteContext(ctx)
+		assert.Equal(t, []string{"start:req-1:echo hello", "start-2", "finish:hello\n"}, events)
+	})
+
+	t.Run("calling the finish hook of a task could not be started", func(t *testing.T) {
+		var result *Result
+
+		MustExec("command-does-not-exist", OnFinish(func(ctx context.Context, task *Task, r Result) {
+			result = &r
+		})).Execute()
+
+		assert.NotNil(t, result)
+		assert.Error(t, result.Err())
+	})
+
+	t.Run("not calling the hooks of a task never started", func(t *testing.T) {
+		var starts, finishes atomic.Int32
+		hooks := []Option{
+			OnStart(func(ctx context.Context, task *Task) { starts.Add(1) }),
+			OnFinish(func(ctx context.Context, task *Task, r Result) { finishes.Add(1) }),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result := MustExec("true", hooks...).ExecuteContext(ctx)
+		assert.True(t, result.Canceled)
+		assert.Zero(t, starts.Load())
+		assert.Zero(t, finishes.Load())
+
+		r, err := NewRunner(WithFailFast(), WithConcurrency(1))
+		assert.NoError(t, err)
+
+		_, err = r.Run(MustExec("false", hooks...), MustExec("true", hooks...), MustExec("true", hooks...))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), starts.Load())
+		assert.Equal(t, int32(1), finishes.Load())
+	})
+
+	t.Run("nil hooks", func(t *testing.T) {
+		_, err := NewExec("echo", OnStart(nil))
+		assert.Error(t, err)
+
+		_, err = NewExec("echo", OnFinish(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf).With().Str("request_id", "req-1").Logger()
+	MustExec("true", WithLogger(logger), WithDebug()).Execute()
+
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.Contains(t, buf.String(), "executing the command")
+}
@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// idleWatchdog tracks the output of a command, and fires once both
+// of its streams have been silent for the idle timeout
+type idleWatchdog struct {
+	timeout time.Duration
+	last    atomic.Int64
+
+	fired chan struct{}
+	stop  chan struct{}
+}
+
+func newIdleWatchdog(timeout time.Duration) *idleWatchdog {
+	return &idleWatchdog{
+		timeout: timeout,
+		fired:   make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Write records the output activity
+func (w *idleWatchdog) Write(p []byte) (int, error) {
+	w.last.Store(time.Now().UnixNano())
+	return len(p), nil
+}
+
+// start starts watching the output, until the watchdog fires or is stopped
+func (w *idleWatchdog) start() {
+	w.last.Store(time.Now().UnixNano())
+
+	go func() {
+		timer := time.NewTimer(w.timeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-timer.C:
+				idle := time.Since(time.Unix(0, w.last.Load()))
+				if idle >= w.timeout {
+					close(w.fired)
+					return
+				}
+
+				timer.Reset(w.timeout - idle)
+			}
+		}
+	}()
+}
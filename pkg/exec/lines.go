@@ -0,0 +1,133 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// StreamStdout identifies the standard output of a task
+	StreamStdout = "stdout"
+	// StreamStderr identifies the standard error of a task
+	StreamStderr = "stderr"
+)
+
+// LineHandler is called for every line written by a task to the given stream,
+// the line is passed without the trailing newline. The calls of a task are serialized,
+// and a line longer than 1MiB is passed in pieces.
+type LineHandler func(stream, line string)
+
+// Color represent an ANSI foreground color code
+type Color int
+
+const (
+	ColorNone    Color = 0
+	ColorRed     Color = 31
+	ColorGreen   Color = 32
+	ColorYellow  Color = 33
+	ColorBlue    Color = 34
+	ColorMagenta Color = 35
+	ColorCyan    Color = 36
+)
+
+// flusher is implemented by the writers buffering an incomplete line
+type flusher interface {
+	Flush() error
+}
+
+// maxLineSize is the maximum size of a line kept by the lineWriter,
+// a longer line is split into lines of this size
+const maxLineSize = 1 << 20
+
+// lineWriter splits the written data into lines, an incomplete line is kept
+// until it is completed or flushed
+type lineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	fn  func(line string) error
+}
+
+func newLineWriter(fn func(line string) error) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+
+		end := i
+		if i < 0 {
+			end = len(p)
+		}
+
+		// the pending line is emitted once it reaches the maximum size
+		if room := maxLineSize - len(w.buf); end >= room {
+			w.buf = append(w.buf, p[:room]...)
+			p = p[room:]
+			if err := w.emit(); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		w.buf = append(w.buf, p[:end]...)
+		if i < 0 {
+			break
+		}
+
+		p = p[i+1:]
+		if err := w.emit(); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// emit emits the pending line
+func (w *lineWriter) emit() error {
+	line := string(bytes.TrimSuffix(w.buf, []byte("\r")))
+	w.buf = w.buf[:0]
+	return w.fn(line)
+}
+
+// Flush emits the incomplete line, if any
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := string(w.buf)
+	w.buf = w.buf[:0]
+	return w.fn(line)
+}
+
+// PrefixWriter writes every line prefixed with a label, optionally colored,
+// similar to the docker-compose logs output
+type PrefixWriter struct {
+	*lineWriter
+}
+
+// NewPrefixWriter returns a new PrefixWriter writing to w, ColorNone disables the coloring
+func NewPrefixWriter(w io.Writer, prefix string, color Color) *PrefixWriter {
+	label := prefix + " | "
+	if color != ColorNone {
+		label = fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, label)
+	}
+
+	return &PrefixWriter{
+		lineWriter: newLineWriter(func(line string) error {
+			_, err := io.WriteString(w, label+line+"\n")
+			return err
+		}),
+	}
+}
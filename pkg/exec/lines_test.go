@@ -0,0 +1,106 @@
+package exec_test
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineHandler(t *testing.T) {
+	t.Run("receiving the lines of both streams", func(t *testing.T) {
+		var (
+			mu    sync.Mutex
+			lines = map[string][]string{}
+		)
+
+		tc, err := NewExec(`printf 'one\ntwo\r\nthree'; echo oops >&2`,
+			WithShell("/bin/sh"),
+			WithLineHandler(func(stream, line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				lines[stream] = append(lines[stream], line)
+			}),
+		)
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, "one\ntwo\r\nthree", result.Stdout)
+		assert.Equal(t, []string{"one", "two", "three"}, lines[StreamStdout])
+		assert.Equal(t, []string{"oops"}, lines[StreamStderr])
+	})
+
+	t.Run("calls are serialized", func(t *testing.T) {
+		var active, overlaps int32
+
+		tc, err := NewExec(`for i in $(seq 200); do echo out$i; echo err$i >&2; done`,
+			WithShell("/bin/sh"),
+			WithLineHandler(func(stream, line string) {
+				if atomic.AddInt32(&active, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(10 * time.Microsecond)
+				atomic.AddInt32(&active, -1)
+			}),
+		)
+		assert.NoError(t, err)
+
+		assert.NoError(t, tc.Execute().Err())
+		assert.Zero(t, atomic.LoadInt32(&overlaps))
+	})
+
+	t.Run("long line without newline is split", func(t *testing.T) {
+		var sizes []int
+
+		tc, err := NewExec(`head -c 2500000 /dev/zero | tr '\0' a`,
+			WithShell("/bin/sh"),
+			WithCaptureLimit(CaptureHead, 1024),
+			WithLineHandler(func(stream, line string) {
+				sizes = append(sizes, len(line))
+			}),
+		)
+		assert.NoError(t, err)
+
+		assert.NoError(t, tc.Execute().Err())
+		assert.Equal(t, []int{1 << 20, 1 << 20, 2500000 - 2<<20}, sizes)
+	})
+
+	t.Run("nil line handler", func(t *testing.T) {
+		_, err := NewExec("echo", WithLineHandler(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Run("prefixing every line", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		w := NewPrefixWriter(&buf, "api", ColorNone)
+		_, err := w.Write([]byte("hello\nwor"))
+		assert.NoError(t, err)
+		assert.Equal(t, "api | hello\n", buf.String())
+
+		_, err = w.Write([]byte("ld\npartial"))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Flush())
+		assert.Equal(t, "api | hello\napi | world\napi | partial\n", buf.String())
+	})
+
+	t.Run("coloring the prefix", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		w := NewPrefixWriter(&buf, "db", ColorCyan)
+		_, err := w.Write([]byte("ready\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "\x1b[36mdb | \x1b[0mready\n", buf.String())
+	})
+
+	t.Run("empty stream prefix", func(t *testing.T) {
+		_, err := NewExec("echo", WithStreamPrefix("", ColorNone))
+		assert.Error(t, err)
+	})
+}
@@ -1,8 +1,16 @@
 package exec
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
 )
 
 type Option func(*Task) error
@@ -28,6 +36,8 @@ func WithArgs(args ...string) Option {
 	}
 }
 
+// WithEnv set the environment variables of the task (key=value), the value may contain "=".
+// The variables override the inherited ones with the same key.
 func WithEnv(envs ...string) Option {
 	return func(t *Task) error {
 		if len(envs) == 0 {
@@ -35,12 +45,53 @@ func WithEnv(envs ...string) Option {
 		}
 
 		for _, env := range envs {
-			if len(strings.Split(env, "=")) == 1 {
+			if key, _ := splitEnv(env); key == "" || !strings.Contains(env, "=") {
 				return fmt.Errorf("environment variable has an invalid format %s, correct format (key=value)", env)
 			}
 		}
 
-		t.env = envs
+		for _, env := range envs {
+			t.env = setEnv(t.env, env)
+		}
+		return nil
+	}
+}
+
+// WithEnvMap set the environment variables of the task from the map,
+// the variables are applied in the sorted order of the keys
+func WithEnvMap(envs map[string]string) Option {
+	return func(t *Task) error {
+		keys := make([]string, 0, len(envs))
+		for key := range envs {
+			if key == "" || strings.Contains(key, "=") {
+				return fmt.Errorf("environment variable has an invalid key %q", key)
+			}
+
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			t.env = setEnv(t.env, key+"="+envs[key])
+		}
+		return nil
+	}
+}
+
+// WithEnvPolicy set which environment variables of the current process are inherited by the task,
+// the allowlist holds the inherited keys for the EnvInheritAllowlist policy
+func WithEnvPolicy(policy EnvPolicy, allowlist ...string) Option {
+	return func(t *Task) error {
+		if policy > EnvClean {
+			return fmt.Errorf("unknown environment policy")
+		}
+
+		if policy == EnvInheritAllowlist && len(allowlist) == 0 {
+			return fmt.Errorf("allowlist couldn't be empty for the allowlist environment policy")
+		}
+
+		t.envPolicy = policy
+		t.envAllowlist = allowlist
 		return nil
 	}
 }
@@ -52,6 +103,10 @@ func WithEnableStreamIO() Option {
 	}
 }
 
+// WithShell runs the command with the given shell, as `shell -c command args...`.
+//
+// Cancellation and timeout only signal the shell itself, the processes it spawned keep
+// running unless the process group management is enabled (see WithProcessGroup).
 func WithShell(shell string) Option {
 	return func(t *Task) error {
 
@@ -71,3 +126,415 @@ func WithDebug() Option {
 		return nil
 	}
 }
+
+// WithDryRun skips the execution of the task, which then returns a synthetic Result
+// holding the rendered command line (see Task.Plan)
+func WithDryRun() Option {
+	return func(t *Task) error {
+		t.dryRun = true
+		return nil
+	}
+}
+
+// WithTimeout set the maximum duration of the task execution,
+// the task is terminated once the timeout is exceeded. Only the command is
+// terminated, its descendants included only with WithProcessGroup.
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *Task) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be greater than zero")
+		}
+
+		t.timeout = timeout
+		return nil
+	}
+}
+
+// WithIdleTimeout terminates the task, like the timeout does, when both of its stdout
+// and stderr have been silent for the given duration, the stdout piped to the next stage
+// of a pipeline included
+func WithIdleTimeout(d time.Duration) Option {
+	return func(t *Task) error {
+		if d <= 0 {
+			return fmt.Errorf("idle timeout must be positive")
+		}
+
+		t.idleTimeout = d
+		return nil
+	}
+}
+
+// WithGracePeriod set the duration to wait after sending SIGTERM before
+// the task is forcibly killed with SIGKILL, zero means kill immediately
+func WithGracePeriod(period time.Duration) Option {
+	return func(t *Task) error {
+		if period < 0 {
+			return fmt.Errorf("grace period couldn't be negative")
+		}
+
+		t.gracePeriod = period
+		return nil
+	}
+}
+
+// WithProcessGroup runs the task in its own process group (Linux only), so that
+// cancellation and timeout signal every descendant of the task, including
+// the processes spawned by the shell, as long as they stay in the group.
+//
+// The whole process group is also killed when the current process dies: a small /bin/sh
+// guard, running beside the task in its own process group, kills the group with SIGKILL
+// once the current process is gone. The task command itself additionally receives the parent
+// death signal (see WithPdeathsig), which is bound to the OS thread starting the command,
+// hence the command is also killed when that thread exits, which only happens when the calling
+// goroutine is locked to its thread (runtime.LockOSThread) and exits without unlocking it.
+// The descendants still running once the task command exited are no longer guarded.
+//
+// The task no longer belongs to the terminal foreground process group,
+// hence it should not be combined with reading from an interactive terminal.
+func WithProcessGroup() Option {
+	return func(t *Task) error {
+		t.processGroup = true
+		return nil
+	}
+}
+
+// WithStdin set the standard input of the task, by default the task reads from os.Stdin.
+// A nil reader makes the task read from the null device.
+func WithStdin(stdin io.Reader) Option {
+	return func(t *Task) error {
+		t.stdin = stdin
+		return nil
+	}
+}
+
+// WithStdout set an additional writer receiving the standard output of the task,
+// the output is still captured into the Result
+func WithStdout(stdout io.Writer) Option {
+	return func(t *Task) error {
+		if stdout == nil {
+			return fmt.Errorf("stdout writer couldn't be nil")
+		}
+
+		t.stdout = stdout
+		return nil
+	}
+}
+
+// WithStderr set an additional writer receiving the standard error of the task,
+// the output is still captured into the Result
+func WithStderr(stderr io.Writer) Option {
+	return func(t *Task) error {
+		if stderr == nil {
+			return fmt.Errorf("stderr writer couldn't be nil")
+		}
+
+		t.stderr = stderr
+		return nil
+	}
+}
+
+// WithLineHandler set a handler called for every line the task writes to stdout and stderr,
+// the handler is never called concurrently by the same task
+func WithLineHandler(handler LineHandler) Option {
+	return func(t *Task) error {
+		if handler == nil {
+			return fmt.Errorf("line handler couldn't be nil")
+		}
+
+		t.lineHandler = handler
+		return nil
+	}
+}
+
+// WithJSONLines set a handler called with every non-blank line the task writes to stdout,
+// decoded as JSON into a value of type T. The decoding stops at the first decode or handler
+// error, which is then reported by Result.Err when the command itself succeeded.
+func WithJSONLines[T any](handler func(T) error) Option {
+	return func(t *Task) error {
+		if handler == nil {
+			return fmt.Errorf("JSON lines handler couldn't be nil")
+		}
+
+		t.jsonLines = func(line []byte) error {
+			var v T
+			if err := json.Unmarshal(line, &v); err != nil {
+				return err
+			}
+
+			return handler(v)
+		}
+		return nil
+	}
+}
+
+// WithStreamPrefix enables the stream passthrough (see WithEnableStreamIO) with every
+// line prefixed by the given name, colored unless the color is ColorNone
+func WithStreamPrefix(name string, color Color) Option {
+	return func(t *Task) error {
+		if name == "" {
+			return fmt.Errorf("stream prefix couldn't be empty")
+		}
+
+		t.streamIO = true
+		t.prefix = name
+		t.prefixColor = color
+		return nil
+	}
+}
+
+// WithCaptureLimit bounds the in-memory capture of stdout and stderr to the limit in bytes,
+// each. Once exceeded, the captured output keeps the part chosen by the mode and
+// a truncation marker is placed where the output has been dropped.
+func WithCaptureLimit(mode CaptureMode, limit int) Option {
+	return func(t *Task) error {
+		if limit <= 0 {
+			return fmt.Errorf("capture limit must be greater than zero")
+		}
+
+		if mode < CaptureHead || mode > CaptureHeadTail {
+			return fmt.Errorf("unknown capture mode")
+		}
+
+		t.captureMode = mode
+		t.captureLimit = limit
+		return nil
+	}
+}
+
+// WithCaptureSpill writes the full stdout and stderr into temporary files in the given
+// directory, or the default temporary directory if empty. The files are kept and referenced
+// by the Result only when the output has been truncated.
+func WithCaptureSpill(dir string) Option {
+	return func(t *Task) error {
+		t.spill = true
+		t.spillDir = dir
+		return nil
+	}
+}
+
+// WithSecret registers the secret values to be redacted
+func WithSecret(values ...string) Option {
+	return func(t *Task) error {
+		for _, v := range values {
+			if v == "" {
+				return fmt.Errorf("secret couldn't be empty")
+			}
+		}
+
+		t.redact.secrets = append(t.redact.secrets, values...)
+		return nil
+	}
+}
+
+// WithRedactKeys registers the patterns of the environment variable keys and the flag names
+// whose values are redacted, in addition to the common ones such as *TOKEN*, *SECRET* or *PASSWORD*.
+// The argument following a flag without a value is redacted only when the pattern matches
+// the whole flag name.
+func WithRedactKeys(patterns ...string) Option {
+	return func(t *Task) error {
+		for _, p := range patterns {
+			rx, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid redact key pattern %q: %w", p, err)
+			}
+
+			t.redact.keys = append(t.redact.keys, rx)
+			t.redact.flags = append(t.redact.flags, regexp.MustCompile(`^(?:`+p+`)$`))
+		}
+		return nil
+	}
+}
+
+// WithRedactPattern registers the regular expressions whose matches are redacted,
+// when the expression has capturing groups only the captured parts are redacted
+func WithRedactPattern(patterns ...string) Option {
+	return func(t *Task) error {
+		for _, p := range patterns {
+			rx, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid redact pattern %q: %w", p, err)
+			}
+
+			t.redact.patterns = append(t.redact.patterns, rx)
+		}
+		return nil
+	}
+}
+
+// WithRedactOutput applies the redaction to the captured stdout and stderr as well
+func WithRedactOutput() Option {
+	return func(t *Task) error {
+		t.redactOutput = true
+		return nil
+	}
+}
+
+// WithLogger set the logger of the task, by default the global zerolog logger is used
+func WithLogger(logger zerolog.Logger) Option {
+	return func(t *Task) error {
+		t.logger = &logger
+		return nil
+	}
+}
+
+// OnStart registers a hook called right before the task is started, it is not called when
+// the context is done beforehand, the hooks are called in the order they are registered
+func OnStart(hook StartHook) Option {
+	return func(t *Task) error {
+		if hook == nil {
+			return fmt.Errorf("start hook couldn't be nil")
+		}
+
+		t.onStart = append(t.onStart, hook)
+		return nil
+	}
+}
+
+// OnFinish registers a hook called once the task has completed, including when it could not
+// be started, only after the start hooks have been called. The hooks are called in the order
+// they are registered.
+func OnFinish(hook FinishHook) Option {
+	return func(t *Task) error {
+		if hook == nil {
+			return fmt.Errorf("finish hook couldn't be nil")
+		}
+
+		t.onFinish = append(t.onFinish, hook)
+		return nil
+	}
+}
+
+// WithUser runs the task as the given user and group ids (Linux only), the supplementary
+// groups of the task are set to the given groups, or cleared when none is given
+func WithUser(uid, gid uint32, groups ...uint32) Option {
+	return func(t *Task) error {
+		if !procAttrSupported {
+			return errProcAttrUnsupported
+		}
+
+		t.procAttr.credential = true
+		t.procAttr.uid = uid
+		t.procAttr.gid = gid
+		t.procAttr.groups = groups
+		return nil
+	}
+}
+
+// WithSetsid runs the task in a new session (Linux only), detached from the controlling terminal
+func WithSetsid() Option {
+	return func(t *Task) error {
+		if !procAttrSupported {
+			return errProcAttrUnsupported
+		}
+
+		t.procAttr.setsid = true
+		return nil
+	}
+}
+
+// WithChroot runs the task with the given root directory (Linux only). The command,
+// and the directory set by WithDirectory, are resolved inside the new root.
+func WithChroot(dir string) Option {
+	return func(t *Task) error {
+		if !procAttrSupported {
+			return errProcAttrUnsupported
+		}
+
+		if dir == "" {
+			return fmt.Errorf("chroot directory couldn't be empty")
+		}
+
+		t.procAttr.chroot = dir
+		return nil
+	}
+}
+
+// WithUmask runs the task with the given file mode creation mask (Linux only).
+// The umask is set by a /bin/sh wrapper replacing itself with the command, the umask
+// of the current process is left untouched.
+func WithUmask(mask int) Option {
+	return func(t *Task) error {
+		if !procAttrSupported {
+			return errProcAttrUnsupported
+		}
+
+		if mask < 0 || mask > 0o777 {
+			return fmt.Errorf("umask must be between 0 and 0777")
+		}
+
+		t.procAttr.umask = mask
+		t.procAttr.hasUmask = true
+		return nil
+	}
+}
+
+// WithPdeathsig set the signal the task receives when the current process dies (Linux only).
+// Only the task command receives the signal, not its descendants, unless the task runs in its
+// own process group. The signal is also sent when the OS thread which started the command
+// exits (see WithProcessGroup).
+func WithPdeathsig(sig syscall.Signal) Option {
+	return func(t *Task) error {
+		if !procAttrSupported {
+			return errProcAttrUnsupported
+		}
+
+		if sig <= 0 {
+			return fmt.Errorf("parent death signal must be a valid signal")
+		}
+
+		t.procAttr.pdeathsig = sig
+		return nil
+	}
+}
+
+// WithRlimit set the soft and hard limits of the resource for the task (Linux only),
+// RlimInfinity means no limit. The task is started through /bin/sh, which stops itself
+// until the limits are set with prlimit, then replaces itself with the task command,
+// so that the command never runs without them. The task is killed when they could not be set.
+func WithRlimit(resource RlimitResource, soft, hard uint64) Option {
+	return func(t *Task) error {
+		if !rlimitSupported {
+			return errRlimitUnsupported
+		}
+
+		if resource < RlimitCPU || resource > RlimitFSIZE {
+			return fmt.Errorf("unknown rlimit resource")
+		}
+
+		if soft > hard {
+			return fmt.Errorf("soft limit of %s couldn't be greater than the hard limit", resource)
+		}
+
+		l := rlimit{resource: resource, soft: soft, hard: hard}
+		for i := range t.rlimits {
+			if t.rlimits[i].resource == resource {
+				t.rlimits[i] = l
+				return nil
+			}
+		}
+
+		t.rlimits = append(t.rlimits, l)
+		return nil
+	}
+}
+
+// WithPTY runs the task attached to a pseudo-terminal of the given window size (Linux only),
+// in a new session. The output is merged into the Result stdout, and the stdin of the task
+// is copied to the terminal: a file is read only while the task runs, any other reader is read
+// until its end. With the stream passthrough enabled (see WithEnableStreamIO) and
+// an interactive terminal as stdin, the terminal is put in raw mode and its window size is followed.
+func WithPTY(rows, cols int) Option {
+	return func(t *Task) error {
+		if !ptySupported {
+			return errPTYUnsupported
+		}
+
+		size, err := newPTYSize(rows, cols)
+		if err != nil {
+			return err
+		}
+
+		t.pty = &size
+		return nil
+	}
+}
@@ -0,0 +1,192 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Pipeline chains the tasks by connecting the stdout of each task to the stdin
+// of the next one, similar to the shell pipeline (cmd1 | cmd2 | cmd3)
+type Pipeline struct {
+	tasks    []*Task
+	pipefail bool
+}
+
+// PipelineOption represent the pipeline option
+type PipelineOption func(*Pipeline) error
+
+// PipelineResult represent the result of the pipeline execution
+type PipelineResult struct {
+	// Results holds the result of every stage, in the pipeline order.
+	// The stdout of a stage other than the last one is consumed by the next stage,
+	// hence it is not captured, while it is still received by the stream passthrough,
+	// the line handler, the JSON lines decoder and the writers of the stage.
+	Results []Result
+
+	// ExitCode is the exit code of the last stage, or when the pipefail is enabled,
+	// the exit code of the last stage exiting with non-zero code
+	ExitCode int
+}
+
+// WithPipefail set the pipeline exit code to the exit code of the last stage
+// exiting with non-zero code, like `set -o pipefail` in the shell
+func WithPipefail() PipelineOption {
+	return func(p *Pipeline) error {
+		p.pipefail = true
+		return nil
+	}
+}
+
+// NewPipeline returns a new Pipeline of the given tasks following with error
+func NewPipeline(tasks []*Task, opts ...PipelineOption) (*Pipeline, error) {
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("pipeline requires at least one task")
+	}
+
+	for i, t := range tasks {
+		if t == nil {
+			return nil, fmt.Errorf("pipeline task at index %d couldn't be nil", i)
+		}
+	}
+
+	p := &Pipeline{tasks: tasks}
+	for _, o := range opts {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Execute runs the pipeline and waits for every stage to complete
+func (p *Pipeline) Execute() PipelineResult {
+	return p.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs the pipeline and waits for every stage to complete, or until
+// the context is done. Each stage keeps its own timeout and grace period.
+func (p *Pipeline) ExecuteContext(ctx context.Context) PipelineResult {
+	n := len(p.tasks)
+
+	// the pipeline is not run at all when any of the stages is in the dry-run mode
+	for _, t := range p.tasks {
+		if !t.dryRun {
+			continue
+		}
+
+		results := make([]Result, n)
+		for i, t := range p.tasks {
+			results[i] = t.dryRunResult()
+		}
+
+		return p.result(results)
+	}
+
+	ctxs := make([]context.Context, n)
+	execs := make([]*execution, n)
+	results := make([]Result, n)
+
+	var prepErr error
+	for i, t := range p.tasks {
+		var cancel context.CancelFunc
+		ctxs[i], cancel = t.withTimeout(ctx)
+		defer cancel()
+
+		var err error
+		if execs[i], err = t.newExecution(); err != nil && prepErr == nil {
+			prepErr = err
+		}
+	}
+
+	// ends holds the pipe ends owned by each stage, which have to be closed
+	// in this process once the stage is started, so that the neighbour stages
+	// observe EOF and broken pipe properly
+	ends := make([][]*os.File, n)
+	for i := 0; i < n-1 && prepErr == nil; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			prepErr = err
+			break
+		}
+
+		execs[i+1].cmd.Stdin = r
+		ends[i+1] = append(ends[i+1], r)
+
+		// the pipe takes the place of the capture, the output is then copied to the pipe
+		// along with the other writers of the stage, which owns the pipe until it exits
+		if writers := execs[i].stdoutWriters; len(writers) > 0 {
+			execs[i].cmd.Stdout = output(w, writers)
+			execs[i].owned = append(execs[i].owned, w)
+			continue
+		}
+
+		execs[i].cmd.Stdout = w
+		ends[i] = append(ends[i], w)
+	}
+
+	// the pipeline is not started at all when any of the stages could not be prepared
+	if prepErr != nil {
+		for i, e := range execs {
+			closeFiles(ends[i])
+			closeFiles(e.owned)
+			results[i] = e.result(ctxs[i], false, prepErr)
+		}
+
+		return p.result(results)
+	}
+
+	started := make([]bool, n)
+	for i, e := range execs {
+		if err := e.start(ctxs[i]); err != nil {
+			closeFiles(e.owned)
+			results[i] = e.result(ctxs[i], false, err)
+		} else {
+			started[i] = true
+		}
+
+		closeFiles(ends[i])
+	}
+
+	var wg sync.WaitGroup
+	for i, e := range execs {
+		if !started[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, e *execution) {
+			defer wg.Done()
+			results[i] = e.wait(ctxs[i])
+		}(i, e)
+	}
+	wg.Wait()
+
+	return p.result(results)
+}
+
+func (p *Pipeline) result(results []Result) PipelineResult {
+	pr := PipelineResult{
+		Results:  results,
+		ExitCode: results[len(results)-1].ExitCode,
+	}
+
+	if p.pipefail {
+		pr.ExitCode = 0
+		for _, r := range results {
+			if r.ExitCode != 0 {
+				pr.ExitCode = r.ExitCode
+			}
+		}
+	}
+
+	return pr
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
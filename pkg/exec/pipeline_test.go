@@ -0,0 +1,145 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPipeline(t *testing.T) {
+	t.Run("pipeline without task", func(t *testing.T) {
+		p, err := NewPipeline(nil)
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	})
+
+	t.Run("pipeline with nil task", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{MustExec("echo"), nil})
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	})
+}
+
+func TestPipelineExecute(t *testing.T) {
+	t.Run("chaining stdout to stdin", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{
+			MustExec("printf", WithArgs(`c\nb\na\n`)),
+			MustExec("sort"),
+			MustExec("head", WithArgs("-n", "1")),
+		})
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.Len(t, result.Results, 3)
+		assert.Equal(t, "a\n", result.Results[2].Stdout)
+		assert.Equal(t, 0, result.ExitCode)
+	})
+
+	t.Run("stage keeps its own environment variables and directory", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{
+			MustExec(`echo "$FOO" && pwd`, WithShell("/bin/sh"), WithEnv("FOO=bar"), WithDirectory("/")),
+			MustExec("cat"),
+		})
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.Equal(t, "bar\n/\n", result.Results[1].Stdout)
+	})
+
+	t.Run("exit code with and without pipefail", func(t *testing.T) {
+		tasks := []*Task{
+			MustExec("exit 3", WithShell("/bin/sh")),
+			MustExec("cat"),
+		}
+
+		p, err := NewPipeline(tasks)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, p.Execute().ExitCode)
+
+		p, err = NewPipeline(tasks, WithPipefail())
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.Equal(t, 3, result.ExitCode)
+		assert.Equal(t, 3, result.Results[0].ExitCode)
+	})
+
+	t.Run("stage failed to start", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{
+			MustExec("echo", WithArgs("hello")),
+			MustExec("command-does-not-exist"),
+		})
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.Equal(t, 1, result.ExitCode)
+		assert.NotEmpty(t, result.Results[1].Stderr)
+	})
+
+	t.Run("pipeline canceled by context", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{
+			MustExec("sleep", WithArgs("5")),
+			MustExec("cat"),
+		})
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result := p.ExecuteContext(ctx)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.Results[0].TimedOut)
+	})
+
+	t.Run("idle timeout of a stage watches the piped output", func(t *testing.T) {
+		p, err := NewPipeline([]*Task{
+			MustExec("for i in 1 2 3 4 5; do echo $i; sleep 0.2; done", WithShell("/bin/sh"), WithIdleTimeout(500*time.Millisecond)),
+			MustExec("cat"),
+		})
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.False(t, result.Results[0].IdleKilled)
+		assert.Equal(t, "1\n2\n3\n4\n5\n", result.Results[1].Stdout)
+		assert.Equal(t, 0, result.ExitCode)
+
+		p, err = NewPipeline([]*Task{
+			MustExec("sleep", WithArgs("5"), WithIdleTimeout(200*time.Millisecond)),
+			MustExec("cat"),
+		})
+		assert.NoError(t, err)
+
+		start := time.Now()
+		result = p.Execute()
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.Results[0].IdleKilled)
+		assert.NoError(t, result.Results[1].Err())
+	})
+
+	t.Run("stage writers receive the piped output", func(t *testing.T) {
+		var (
+			lines []string
+			buf   bytes.Buffer
+		)
+
+		p, err := NewPipeline([]*Task{
+			MustExec("printf", WithArgs(`a\nb\n`),
+				WithLineHandler(func(stream, line string) { lines = append(lines, stream+":"+line) }),
+				WithStdout(&buf),
+			),
+			MustExec("cat"),
+		})
+		assert.NoError(t, err)
+
+		result := p.Execute()
+		assert.Equal(t, []string{"stdout:a", "stdout:b"}, lines)
+		assert.Equal(t, "a\nb\n", buf.String())
+		assert.Empty(t, result.Results[0].Stdout)
+		assert.Equal(t, "a\nb\n", result.Results[1].Stdout)
+	})
+}
@@ -0,0 +1,23 @@
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errProcAttrUnsupported is returned by the process attribute options on the unsupported platforms
+var errProcAttrUnsupported = errors.New("process attributes are only supported on Linux")
+
+// procAttr holds the attributes of the process, applied through the SysProcAttr
+type procAttr struct {
+	credential bool
+	uid        uint32
+	gid        uint32
+	groups     []uint32
+
+	setsid    bool
+	chroot    string
+	umask     int
+	hasUmask  bool
+	pdeathsig syscall.Signal
+}
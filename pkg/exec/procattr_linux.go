@@ -0,0 +1,76 @@
+//go:build linux
+
+package exec
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const procAttrSupported = true
+
+// applyProcAttr sets the process attributes into the command SysProcAttr
+func applyProcAttr(cmd *exec.Cmd, attr procAttr) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	if attr.credential {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid:    attr.uid,
+			Gid:    attr.gid,
+			Groups: attr.groups,
+		}
+	}
+
+	if attr.setsid {
+		cmd.SysProcAttr.Setsid = true
+	}
+
+	if attr.chroot != "" {
+		cmd.SysProcAttr.Chroot = attr.chroot
+	}
+
+	if attr.pdeathsig != 0 {
+		cmd.SysProcAttr.Pdeathsig = attr.pdeathsig
+	}
+}
+
+// wrapCommand wraps the command with a shell setting the umask, and stopping itself until
+// the resource limits are set when held, before replacing itself with the command, which
+// inherits both. No redirection is used, as the shells save the redirected file descriptors
+// above 10, which may exceed the limits. The command is not wrapped when it could not be
+// started anyway, so that its start error is kept. It reports whether the command is held.
+func wrapCommand(cmd *exec.Cmd, attr procAttr, hold bool) bool {
+	var steps []string
+	if attr.hasUmask {
+		steps = append(steps, fmt.Sprintf("umask %04o", attr.umask))
+	}
+
+	if hold {
+		steps = append(steps, "kill -STOP $$")
+	}
+
+	if len(steps) == 0 || cmd.Err != nil {
+		return false
+	}
+
+	path := cmd.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cmd.Dir, path)
+	}
+
+	if unix.Access(filepath.Join(attr.chroot, path), unix.X_OK) != nil {
+		return false
+	}
+
+	steps = append(steps, `exec "$@"`)
+	cmd.Args = append([]string{"sh", "-c", strings.Join(steps, " && "), "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+	return hold
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package exec
+
+import "os/exec"
+
+const procAttrSupported = false
+
+// applyProcAttr is a no-op on this platform
+func applyProcAttr(cmd *exec.Cmd, attr procAttr) {}
+
+// wrapCommand is a no-op on this platform
+func wrapCommand(cmd *exec.Cmd, attr procAttr, hold bool) bool {
+	return false
+}
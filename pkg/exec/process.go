@@ -0,0 +1,115 @@
+package exec
+
+import (
+	"context"
+	"os"
+)
+
+// Process represent a started task, running asynchronously
+type Process struct {
+	e    *execution
+	done chan struct{}
+
+	result Result
+}
+
+// Start starts the task without waiting for it to complete
+func (t *Task) Start() (*Process, error) {
+	return t.StartContext(context.Background())
+}
+
+// StartContext starts the task without waiting for it to complete. The process
+// is terminated when the context is done, or when the task timeout is exceeded.
+func (t *Task) StartContext(ctx context.Context) (*Process, error) {
+	p, err := t.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// start starts the task, the returned Process is never nil.
+// When the task could not be started, the Process is already done
+// and its result describes the failure.
+func (t *Task) start(ctx context.Context) (*Process, error) {
+	ctx, cancel := t.withTimeout(ctx)
+
+	p := &Process{done: make(chan struct{})}
+
+	if t.dryRun {
+		defer cancel()
+		p.e = &execution{
+			task:   t,
+			stdout: newCapture(t.captureMode, t.captureLimit),
+			stderr: newCapture(t.captureMode, t.captureLimit),
+		}
+		p.result = t.dryRunResult()
+		close(p.done)
+		return p, nil
+	}
+
+	e, err := t.newExecution()
+	p.e = e
+	if err == nil {
+		err = e.start(ctx)
+	}
+
+	if err != nil {
+		defer cancel()
+		p.result = e.result(ctx, false, err)
+		close(p.done)
+		return p, err
+	}
+
+	go func() {
+		defer cancel()
+		p.result = e.wait(ctx)
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// PID returns the process id, zero when the process has not been started
+func (p *Process) PID() int {
+	if p.e.cmd == nil || p.e.cmd.Process == nil {
+		return 0
+	}
+
+	return p.e.cmd.Process.Pid
+}
+
+// Signal sends the signal to the process, or to its whole process group
+// when the process group management is enabled
+func (p *Process) Signal(sig os.Signal) error {
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	default:
+	}
+
+	return p.e.task.signal(p.e.cmd, sig)
+}
+
+// Wait waits for the process to complete and returns its result,
+// it is safe to be called multiple times
+func (p *Process) Wait() Result {
+	<-p.done
+	return p.result
+}
+
+// Done returns a channel that is closed once the process has completed
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Stdout returns the standard output captured so far
+func (p *Process) Stdout() string {
+	return p.e.captured(p.e.stdout)
+}
+
+// Stderr returns the standard error captured so far
+func (p *Process) Stderr() string {
+	return p.e.captured(p.e.stderr)
+}
@@ -0,0 +1,67 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("signaling the running process", func(t *testing.T) {
+		p, err := MustExec("sleep", WithArgs("5")).Start()
+		assert.NoError(t, err)
+		assert.Greater(t, p.PID(), 0)
+
+		select {
+		case <-p.Done():
+			t.Fatal("process should be still running")
+		default:
+		}
+
+		assert.NoError(t, p.Signal(syscall.SIGTERM))
+
+		result := p.Wait()
+		assert.Equal(t, -1, result.ExitCode)
+		assert.Equal(t, result, p.Wait())
+		assert.ErrorIs(t, p.Signal(syscall.SIGTERM), os.ErrProcessDone)
+	})
+
+	t.Run("reading the output captured so far", func(t *testing.T) {
+		p, err := MustExec("echo ready; exec sleep 5", WithShell("/bin/sh")).Start()
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			return strings.Contains(p.Stdout(), "ready")
+		}, 2*time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, p.Signal(syscall.SIGKILL))
+		assert.Equal(t, "ready\n", p.Wait().Stdout)
+	})
+
+	t.Run("process terminated by context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		p, err := MustExec("sleep", WithArgs("5")).StartContext(ctx)
+		assert.NoError(t, err)
+
+		cancel()
+		select {
+		case <-p.Done():
+		case <-time.After(2 * time.Second):
+			t.Fatal("process should be terminated")
+		}
+		assert.True(t, p.Wait().Canceled)
+	})
+
+	t.Run("command could not be started", func(t *testing.T) {
+		p, err := MustExec("command-does-not-exist").Start()
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,73 @@
+//go:build linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// groupGuardScript waits for the release of the guard, and kills the process group
+// given as the first argument when the input ends without it, that is when the
+// current process died and its end of the pipe has been closed by the kernel
+const groupGuardScript = `read -r _ || kill -s KILL -- "-$1"`
+
+// setProcessGroup places the command into its own process group, so that every
+// descendant can be signaled at once, and asks the kernel to kill the command
+// when the parent process dies
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	// a session leader is already the leader of its own process group
+	cmd.SysProcAttr.Setpgid = !cmd.SysProcAttr.Setsid
+	if cmd.SysProcAttr.Pdeathsig == 0 {
+		cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
+	}
+}
+
+// signalProcessGroup sends the signal to every process in the command process group
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// groupGuard is a helper process killing the whole process group of the command
+// when the current process dies, since the parent death signal only reaches the command
+type groupGuard struct {
+	cmd *exec.Cmd
+	w   *os.File
+}
+
+// startGroupGuard starts the guard of the process group, in its own process group,
+// so that it does not receive the signals sent to the guarded one
+func startGroupGuard(pgid int) (*groupGuard, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command("/bin/sh", "-c", groupGuardScript, "sh", strconv.Itoa(pgid))
+	cmd.Stdin = r
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	return &groupGuard{cmd: cmd, w: w}, nil
+}
+
+// release stops the guard, leaving the process group untouched
+func (g *groupGuard) release() {
+	if g == nil {
+		return
+	}
+
+	_, _ = g.w.Write([]byte("\n"))
+	g.w.Close()
+	_ = g.cmd.Wait()
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op on this platform
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup sends the signal only to the command process on this platform
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// groupGuard is not supported on this platform
+type groupGuard struct{}
+
+// startGroupGuard is a no-op on this platform
+func startGroupGuard(pgid int) (*groupGuard, error) {
+	return nil, nil
+}
+
+// release is a no-op on this platform
+func (g *groupGuard) release() {}
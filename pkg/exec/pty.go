@@ -0,0 +1,70 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// errPTYUnsupported is returned by WithPTY on the unsupported platforms
+var errPTYUnsupported = errors.New("pty is only supported on Linux")
+
+// ptySize is the window size of the pseudo-terminal
+type ptySize struct {
+	rows uint16
+	cols uint16
+}
+
+// pty is the pseudo-terminal pair of an execution
+type pty struct {
+	master *os.File
+	tty    *os.File
+
+	// out receives the output read from the master side, in is copied to it
+	out io.Writer
+	in  io.Reader
+
+	// raw puts the input terminal, if any, in raw mode
+	raw bool
+
+	done    chan struct{}
+	restore func()
+}
+
+// closeTTY closes the terminal side, once the command holds it
+func (p *pty) closeTTY() {
+	if p.tty != nil {
+		p.tty.Close()
+	}
+}
+
+// Resize changes the window size of the process pseudo-terminal (see WithPTY)
+func (p *Process) Resize(rows, cols int) error {
+	if p.e.pty == nil {
+		return errors.New("the process has no pty")
+	}
+
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	default:
+	}
+
+	size, err := newPTYSize(rows, cols)
+	if err != nil {
+		return err
+	}
+
+	return setWindowSize(p.e.pty.master, size)
+}
+
+// newPTYSize returns the window size, validated
+func newPTYSize(rows, cols int) (ptySize, error) {
+	if rows <= 0 || cols <= 0 || rows > math.MaxUint16 || cols > math.MaxUint16 {
+		return ptySize{}, fmt.Errorf("invalid pty window size %dx%d", rows, cols)
+	}
+
+	return ptySize{rows: uint16(rows), cols: uint16(cols)}, nil
+}
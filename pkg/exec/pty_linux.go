@@ -0,0 +1,220 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const ptySupported = true
+
+// ptyInputPoll is the interval the input is polled at, to check for the command completion
+const ptyInputPoll = 100 * time.Millisecond
+
+// openPTY allocates a pseudo-terminal pair and connects the command to its terminal side,
+// the command runs in a new session with the terminal as its controlling terminal
+func openPTY(cmd *exec.Cmd, size ptySize) (*pty, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the pty: %w", err)
+	}
+
+	tty, err := openTTY(master)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("could not open the pty terminal: %w", err)
+	}
+
+	if err := setWindowSize(master, size); err != nil {
+		master.Close()
+		tty.Close()
+		return nil, err
+	}
+
+	p := &pty{
+		master: master,
+		tty:    tty,
+		out:    cmd.Stdout,
+		in:     cmd.Stdin,
+		done:   make(chan struct{}),
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// the session leader is also the leader of its process group
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+
+	return p, nil
+}
+
+// openTTY unlocks and opens the terminal side of the master
+func openTTY(master *os.File) (*os.File, error) {
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		return nil, err
+	}
+
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+}
+
+// setWindowSize sets the window size of the pseudo-terminal
+func setWindowSize(f *os.File, size ptySize) error {
+	ws := &unix.Winsize{Row: size.rows, Col: size.cols}
+	if err := unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		return fmt.Errorf("could not set the pty window size: %w", err)
+	}
+
+	return nil
+}
+
+// start copies the output of the started command, and its input when set.
+// In raw mode, when the input is the interactive terminal of this process, the terminal
+// is put in raw mode and its window size is followed until the command completes.
+func (p *pty) start() {
+	p.closeTTY()
+
+	go func() {
+		defer close(p.done)
+		// reading fails with EIO once every holder of the terminal side has exited
+		_, _ = io.Copy(p.out, p.master)
+	}()
+
+	if p.in == nil {
+		return
+	}
+
+	if f, ok := p.in.(*os.File); ok && p.raw && isTerminal(f) {
+		p.restore = p.interactive(f)
+	}
+
+	go p.copyInput()
+}
+
+// copyInput copies the input to the terminal until the input ends or the command completes.
+// A file input is polled before being read, so that the input following the completion
+// of the command is left unread. Any other reader is read until its end.
+func (p *pty) copyInput() {
+	f, ok := p.in.(*os.File)
+	if !ok {
+		_, _ = io.Copy(p.master, p.in)
+		return
+	}
+
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+
+		ready := false
+		if err := rc.Control(func(fd uintptr) {
+			fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+			n, err := unix.Poll(fds, int(ptyInputPoll/time.Millisecond))
+			ready = err == nil && n > 0
+		}); err != nil {
+			return
+		}
+
+		if !ready {
+			continue
+		}
+
+		// the input following the completion is left unread
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, err := p.master.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait waits for the output to be copied, then releases the pseudo-terminal
+func (p *pty) wait() {
+	<-p.done
+
+	if p.restore != nil {
+		p.restore()
+	}
+
+	p.master.Close()
+}
+
+// interactive puts the terminal in raw mode and follows its window size,
+// the returned function restores the terminal
+func (p *pty) interactive(f *os.File) func() {
+	fd := int(f.Fd())
+
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil
+	}
+
+	raw := *state
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil
+	}
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	go func() {
+		for range winch {
+			if ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil {
+				_ = unix.IoctlSetWinsize(int(p.master.Fd()), unix.TIOCSWINSZ, ws)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(winch)
+		close(winch)
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, state)
+	}
+}
+
+// isTerminal reports whether the file is a terminal
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	return err == nil
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+)
+
+const ptySupported = false
+
+// openPTY is not supported on this platform
+func openPTY(cmd *exec.Cmd, size ptySize) (*pty, error) {
+	return nil, errPTYUnsupported
+}
+
+// start is a no-op on this platform
+func (p *pty) start() {}
+
+// wait is a no-op on this platform
+func (p *pty) wait() {}
+
+// setWindowSize is not supported on this platform
+func setWindowSize(f *os.File, size ptySize) error {
+	return errPTYUnsupported
+}
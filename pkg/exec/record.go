@@ -0,0 +1,94 @@
+package exec
+
+import "time"
+
+// ResultRecord is the JSON-serializable form of the Result, for instance to be stored
+// in a job history. The environment is left out as it likely holds secrets,
+// and the secrets are redacted from the arguments.
+type ResultRecord struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args,omitempty"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr"`
+	ExitCode int      `json:"exit_code"`
+
+	Canceled   bool   `json:"canceled,omitempty"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	IdleKilled bool   `json:"idle_killed,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	StdoutFile string `json:"stdout_file,omitempty"`
+	StderrFile string `json:"stderr_file,omitempty"`
+
+	StartedAt    time.Time `json:"started_at"`
+	DurationMS   int64     `json:"duration_ms"`
+	UserTimeMS   int64     `json:"user_time_ms"`
+	SystemTimeMS int64     `json:"system_time_ms"`
+	MaxRSS       int64     `json:"max_rss_bytes,omitempty"`
+	Signal       int       `json:"signal,omitempty"`
+	SignalName   string    `json:"signal_name,omitempty"`
+
+	LimitExceeded string `json:"limit_exceeded,omitempty"`
+	FailedLine    int    `json:"failed_line,omitempty"`
+
+	Attempts int `json:"attempts,omitempty"`
+
+	DryRun bool   `json:"dry_run,omitempty"`
+	Plan   string `json:"plan,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Record returns the JSON-serializable form of the Result
+func (r Result) Record() ResultRecord {
+	rec := ResultRecord{
+		Command:      r.Command,
+		Args:         r.redactedArgs(),
+		Stdout:       r.Stdout,
+		Stderr:       r.Stderr,
+		ExitCode:     r.ExitCode,
+		Canceled:     r.Canceled,
+		TimedOut:     r.TimedOut,
+		IdleKilled:   r.IdleKilled,
+		Truncated:    r.Truncated,
+		StdoutFile:   r.StdoutFile,
+		StderrFile:   r.StderrFile,
+		StartedAt:    r.StartedAt,
+		DurationMS:   r.Duration.Milliseconds(),
+		UserTimeMS:   r.UserTime.Milliseconds(),
+		SystemTimeMS: r.SystemTime.Milliseconds(),
+		MaxRSS:       r.MaxRSS,
+		FailedLine:   r.FailedLine,
+		Attempts:     len(r.Attempts),
+		DryRun:       r.DryRun,
+		Plan:         r.Plan,
+	}
+
+	if r.Signal != 0 {
+		rec.Signal = int(r.Signal)
+		rec.SignalName = r.Signal.String()
+	}
+
+	if r.LimitExceeded != 0 {
+		rec.LimitExceeded = r.LimitExceeded.String()
+	}
+
+	if err := r.Err(); err != nil {
+		rec.Error = err.Error()
+	}
+
+	return rec
+}
+
+// redactedArgs returns the arguments with the secrets of the task redacted, or with
+// the common secrets redacted when the Result has not been returned by a task
+func (r Result) redactedArgs() []string {
+	if r.safeArgs != nil {
+		return r.safeArgs
+	}
+
+	if r.Args == nil {
+		return nil
+	}
+
+	return (&redactor{}).args(r.Args)
+}
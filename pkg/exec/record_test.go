@@ -0,0 +1,76 @@
+package exec_test
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultUsage(t *testing.T) {
+	t.Run("timing and resource usage", func(t *testing.T) {
+		before := time.Now()
+		result := MustExec("sleep", WithArgs("0.1")).Execute()
+
+		assert.False(t, result.StartedAt.Before(before))
+		assert.GreaterOrEqual(t, result.Duration, 100*time.Millisecond)
+		assert.GreaterOrEqual(t, result.UserTime, time.Duration(0))
+		assert.Zero(t, result.Signal)
+	})
+
+	t.Run("terminating signal", func(t *testing.T) {
+		result := MustExec("sleep", WithArgs("5"), WithTimeout(50*time.Millisecond)).Execute()
+		assert.Equal(t, syscall.SIGTERM, result.Signal)
+	})
+
+	t.Run("command could not be started", func(t *testing.T) {
+		result := MustExec("command-does-not-exist").Execute()
+		assert.True(t, result.StartedAt.IsZero())
+		assert.Zero(t, result.Duration)
+	})
+}
+
+func TestResultRecord(t *testing.T) {
+	t.Run("serializing the record", func(t *testing.T) {
+		result := MustExec("kill -9 $$", WithShell("/bin/sh"), WithEnv("SECRET=hunter2")).Execute()
+
+		data, err := json.Marshal(result.Record())
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "hunter2")
+
+		var rec ResultRecord
+		assert.NoError(t, json.Unmarshal(data, &rec))
+		assert.Equal(t, "/bin/sh", rec.Command)
+		assert.Equal(t, -1, rec.ExitCode)
+		assert.Equal(t, 9, rec.Signal)
+		assert.Equal(t, "killed", rec.SignalName)
+		assert.Contains(t, rec.Error, "killed by signal 9")
+		assert.Equal(t, result.StartedAt.UnixNano(), rec.StartedAt.UnixNano())
+	})
+
+	t.Run("secrets are redacted from the arguments", func(t *testing.T) {
+		result := MustExec("echo",
+			WithArgs("--token=abc123", "--password", "hunter2", "deploy", "key-s3cret"),
+			WithSecret("s3cret"),
+		).Execute()
+
+		assert.Equal(t, []string{"--token=abc123", "--password", "hunter2", "deploy", "key-s3cret"}, result.Args)
+		assert.Equal(t, []string{"--token=REDACTED", "--password", "REDACTED", "deploy", "key-REDACTED"}, result.Record().Args)
+
+		result = Result{Command: "login", Args: []string{"--api-key", "k"}}
+		assert.Equal(t, []string{"--api-key", "REDACTED"}, result.Record().Args)
+	})
+
+	t.Run("result keeps its default JSON encoding", func(t *testing.T) {
+		data, err := json.Marshal(Result{Command: "true", ExitCode: 3})
+		assert.NoError(t, err)
+
+		var result Result
+		assert.NoError(t, json.Unmarshal(data, &result))
+		assert.Equal(t, "true", result.Command)
+		assert.Equal(t, 3, result.ExitCode)
+	})
+}
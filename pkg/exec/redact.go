@@ -0,0 +1,159 @@
+package exec
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the secrets in the logs, the command line rendering and,
+// when enabled, the captured output
+const Redacted = "REDACTED"
+
+// defaultRedactKeys matches the environment variable keys and the flag names
+// which commonly hold a secret
+var defaultRedactKeys = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credential)`)
+
+// defaultRedactFlags matches the flag names taking a secret as the next argument, the name
+// has to end with a sensitive word, so that --auth-token is sensitive but --no-token-refresh is not
+var defaultRedactFlags = regexp.MustCompile(`(?i)(^|[-_.])(secret|token|passw(or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)$`)
+
+// assignment matches the key=value assignments, including the --flag=value form
+var assignment = regexp.MustCompile(`(-{0,2}[A-Za-z_][A-Za-z0-9_.-]*)=("[^"]*"|'[^']*'|[^\s"']+)`)
+
+// redactor masks the secrets found in the arguments, the environment and any text
+type redactor struct {
+	keys []*regexp.Regexp
+	// flags holds the keys anchored to match the whole flag name
+	flags    []*regexp.Regexp
+	patterns []*regexp.Regexp
+	secrets  []string
+}
+
+// sensitive reports whether the environment variable key or the flag name holds a secret
+func (r *redactor) sensitive(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	if defaultRedactKeys.MatchString(key) {
+		return true
+	}
+
+	for _, rx := range r.keys {
+		if rx.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sensitiveFlag reports whether the flag, without a value, takes a secret as the next argument
+func (r *redactor) sensitiveFlag(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	if defaultRedactFlags.MatchString(name) {
+		return true
+	}
+
+	for _, rx := range r.flags {
+		if rx.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// text masks the explicit secrets, the values assigned to the sensitive keys and
+// the matches of the patterns. When a pattern has capturing groups, only the captured
+// parts are masked.
+func (r *redactor) text(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+
+	s = assignment.ReplaceAllStringFunc(s, func(m string) string {
+		key, _, _ := strings.Cut(m, "=")
+		if r.sensitive(strings.TrimLeft(key, "-")) {
+			return key + "=" + Redacted
+		}
+
+		return m
+	})
+
+	for _, rx := range r.patterns {
+		if rx.NumSubexp() == 0 {
+			s = rx.ReplaceAllLiteralString(s, Redacted)
+			continue
+		}
+
+		var sb strings.Builder
+		last := 0
+		for _, m := range rx.FindAllStringSubmatchIndex(s, -1) {
+			for g := 2; g < len(m); g += 2 {
+				if m[g] < 0 || m[g] < last {
+					continue
+				}
+
+				sb.WriteString(s[last:m[g]])
+				sb.WriteString(Redacted)
+				last = m[g+1]
+			}
+		}
+		sb.WriteString(s[last:])
+		s = sb.String()
+	}
+
+	return s
+}
+
+// args masks the values of the sensitive flags, either --flag=value, where the flag name
+// contains a sensitive key, or --flag value, where the flag name is a sensitive one
+func (r *redactor) args(args []string) []string {
+	redacted := make([]string, len(args))
+
+	maskNext := false
+	for i, arg := range args {
+		if maskNext {
+			redacted[i] = Redacted
+			maskNext = false
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if hasValue && r.sensitive(name) {
+				redacted[i] = arg[:strings.Index(arg, "=")+1] + Redacted
+				continue
+			}
+
+			if !hasValue && r.sensitiveFlag(name) {
+				redacted[i] = arg
+				maskNext = true
+				continue
+			}
+		}
+
+		redacted[i] = r.text(arg)
+	}
+
+	return redacted
+}
+
+// env masks the values of the sensitive environment variables
+func (r *redactor) env(envs []string) []string {
+	redacted := make([]string, len(envs))
+	for i, env := range envs {
+		if key, _ := splitEnv(env); r.sensitive(key) {
+			redacted[i] = key + "=" + Redacted
+			continue
+		}
+
+		redacted[i] = r.text(env)
+	}
+
+	return redacted
+}
@@ -0,0 +1,109 @@
+package exec_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskString(t *testing.T) {
+	testcases := []struct {
+		name string
+		task *Task
+		want string
+	}{
+		{
+			name: "quoting the arguments",
+			task: MustExec("git", WithArgs("log", "--format=%H %s", "it's")),
+			want: `git log '--format=%H %s' 'it'\''s'`,
+		},
+		{
+			name: "redacting the sensitive flags",
+			task: MustExec("vault", WithArgs("login", "--token=s.abc", "--password", "hunter2", "--author=me")),
+			want: "vault login --token=REDACTED --password REDACTED --author=me",
+		},
+		{
+			name: "keeping the argument of the boolean flags",
+			task: MustExec("deploy", WithArgs("--no-token-refresh", "hello", "--auth-token", "s.abc", "--api-key", "k", "--tokens=3")),
+			want: "deploy --no-token-refresh hello --auth-token REDACTED --api-key REDACTED --tokens=REDACTED",
+		},
+		{
+			name: "redacting the explicit secrets",
+			task: MustExec("curl", WithArgs("-u", "admin:hunter2", "example.com"), WithSecret("hunter2")),
+			want: "curl -u admin:REDACTED example.com",
+		},
+		{
+			name: "redacting the patterns",
+			task: MustExec("curl", WithArgs("-H", "Authorization: Bearer abc.def"), WithRedactPattern(`Bearer (\S+)`)),
+			want: "curl -H 'Authorization: Bearer REDACTED'",
+		},
+		{
+			name: "redacting the custom keys",
+			task: MustExec("deploy", WithArgs("--dsn", "postgres://u:p@db", "--dsn-check", "on"), WithRedactKeys(`(?i)dsn`)),
+			want: "deploy --dsn REDACTED --dsn-check on",
+		},
+		{
+			name: "redacting the shell script",
+			task: MustExec("GITHUB_TOKEN=ghp_123 gh pr list", WithShell("/bin/sh")),
+			want: "/bin/sh -c 'GITHUB_TOKEN=REDACTED gh pr list'",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.task.String())
+		})
+	}
+
+	t.Run("invalid redaction options", func(t *testing.T) {
+		_, err := NewExec("echo", WithSecret(""))
+		assert.Error(t, err)
+
+		_, err = NewExec("echo", WithRedactKeys("("))
+		assert.Error(t, err)
+
+		_, err = NewExec("echo", WithRedactPattern("("))
+		assert.Error(t, err)
+	})
+}
+
+func TestExecuteWithRedaction(t *testing.T) {
+	t.Run("redacting the debug log", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := log.Logger
+		log.Logger = zerolog.New(&buf).Level(zerolog.DebugLevel)
+		defer func() { log.Logger = logger }()
+
+		MustExec("true",
+			WithArgs("--token=s.abc"),
+			WithEnv("AWS_SECRET_ACCESS_KEY=wJalr", "REGION=us-east-1"),
+			WithDebug(),
+		).Execute()
+
+		assert.NotContains(t, buf.String(), "s.abc")
+		assert.NotContains(t, buf.String(), "wJalr")
+		assert.Contains(t, buf.String(), "AWS_SECRET_ACCESS_KEY=REDACTED")
+		assert.Contains(t, buf.String(), "REGION=us-east-1")
+	})
+
+	t.Run("redacting the captured output", func(t *testing.T) {
+		result := MustExec(`echo "password is hunter2"; echo "API_KEY=abc" >&2`,
+			WithShell("/bin/sh"),
+			WithSecret("hunter2"),
+			WithRedactOutput(),
+		).Execute()
+
+		assert.Equal(t, "password is REDACTED\n", result.Stdout)
+		assert.Equal(t, "API_KEY=REDACTED\n", result.Stderr)
+	})
+
+	t.Run("keeping the captured output by default", func(t *testing.T) {
+		result := MustExec("echo", WithArgs("hunter2"), WithSecret("hunter2")).Execute()
+		assert.Equal(t, "hunter2\n", result.Stdout)
+	})
+}
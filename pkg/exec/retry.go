@@ -0,0 +1,258 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
+	defaultRetryJitter         = 0.2
+)
+
+// RetryPredicate reports whether the failed result should be retried
+type RetryPredicate func(Result) bool
+
+// RetryOption represent the retry policy option
+type RetryOption func(*retryPolicy) error
+
+// retryPolicy defines how many times and when a failed task is executed again
+type retryPolicy struct {
+	attempts   int
+	initial    time.Duration
+	max        time.Duration
+	jitter     float64
+	predicates []RetryPredicate
+}
+
+// WithRetry executes the task again when it fails, up to the given number of attempts
+// in total, waiting between the attempts with an exponential backoff. By default every
+// failure is retried, unless the context is done; see RetryIf to restrict the failures
+// to retry. The retries apply to Execute, ExecuteContext and the runners, not to Start.
+// Every attempt reads the same stdin: a seekable file is rewound, and any other reader
+// is kept in memory as it is read, to be replayed. A stdin file which is not seekable,
+// such as the inherited terminal, is shared by the attempts.
+func WithRetry(attempts int, opts ...RetryOption) Option {
+	return func(t *Task) error {
+		if attempts < 1 {
+			return fmt.Errorf("retry attempts must be at least one")
+		}
+
+		p := &retryPolicy{
+			attempts: attempts,
+			initial:  defaultRetryInitialBackoff,
+			max:      defaultRetryMaxBackoff,
+			jitter:   defaultRetryJitter,
+		}
+
+		for _, o := range opts {
+			if err := o(p); err != nil {
+				return err
+			}
+		}
+
+		t.retry = p
+		return nil
+	}
+}
+
+// WithBackoff set the backoff before the first retry, doubled on every retry up to the max,
+// by default 1s up to 30s
+func WithBackoff(initial, max time.Duration) RetryOption {
+	return func(p *retryPolicy) error {
+		if initial <= 0 || max < initial {
+			return fmt.Errorf("invalid retry backoff %s up to %s", initial, max)
+		}
+
+		p.initial = initial
+		p.max = max
+		return nil
+	}
+}
+
+// WithJitter set the fraction of the backoff which is randomly removed, from 0 (no jitter)
+// to 1 (full jitter), by default 0.2
+func WithJitter(fraction float64) RetryOption {
+	return func(p *retryPolicy) error {
+		if fraction < 0 || fraction > 1 {
+			return fmt.Errorf("retry jitter must be between 0 and 1")
+		}
+
+		p.jitter = fraction
+		return nil
+	}
+}
+
+// RetryIf restricts the retried failures to the ones matching any of the predicates
+func RetryIf(predicates ...RetryPredicate) RetryOption {
+	return func(p *retryPolicy) error {
+		for _, predicate := range predicates {
+			if predicate == nil {
+				return fmt.Errorf("retry predicate couldn't be nil")
+			}
+		}
+
+		p.predicates = append(p.predicates, predicates...)
+		return nil
+	}
+}
+
+// RetryOnExitCodes matches the results exiting with any of the given exit codes
+func RetryOnExitCodes(codes ...int) RetryPredicate {
+	return func(r Result) bool {
+		for _, code := range codes {
+			if r.ExitCode == code {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// RetryOnStderr matches the results whose stderr matches the regular expression
+func RetryOnStderr(rx *regexp.Regexp) RetryPredicate {
+	return func(r Result) bool {
+		return rx.MatchString(r.Stderr)
+	}
+}
+
+// RetryOnTimeout matches the results which exceeded the task timeout or the idle timeout
+func RetryOnTimeout() RetryPredicate {
+	return func(r Result) bool {
+		return r.TimedOut || r.IdleKilled
+	}
+}
+
+// retryable reports whether the result should be retried
+func (p *retryPolicy) retryable(ctx context.Context, r Result) bool {
+	if !failedResult(r) || ctx.Err() != nil || r.DryRun {
+		return false
+	}
+
+	if len(p.predicates) == 0 {
+		return true
+	}
+
+	for _, predicate := range p.predicates {
+		if predicate(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the duration to wait before the given retry, starting from 1
+func (p *retryPolicy) backoff(retry int) time.Duration {
+	d := p.initial
+	for i := 1; i < retry && d < p.max; i++ {
+		d *= 2
+	}
+
+	if d > p.max {
+		d = p.max
+	}
+
+	return d - time.Duration(p.jitter*rand.Float64()*float64(d))
+}
+
+// executeWithRetry executes the task until it succeeds, the failure is not retryable,
+// the attempts are exhausted or the context is done. The result of the last attempt
+// is returned, holding the results of every attempt.
+func (t *Task) executeWithRetry(ctx context.Context) Result {
+	stdin := newStdinReplay(t.stdin)
+
+	var attempts []Result
+	for {
+		tc := *t
+		tc.stdin = stdin.reader()
+
+		p, _ := tc.start(ctx)
+		result := p.Wait()
+		attempts = append(attempts, result)
+
+		if len(attempts) >= t.retry.attempts || !t.retry.retryable(ctx, result) {
+			result.Attempts = attempts
+			return result
+		}
+
+		delay := t.retry.backoff(len(attempts))
+		if t.debug {
+			logger := t.baseLogger()
+			logger.Debug().
+				Int("attempt", len(attempts)).
+				Int("exit_code", result.ExitCode).
+				Dur("backoff", delay).
+				Msg("the command failed, retrying")
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result.Attempts = attempts
+			return result
+		case <-timer.C:
+		}
+
+		// the failed attempt is the last one when its stdin could not be replayed
+		if err := stdin.rewind(); err != nil {
+			if t.debug {
+				logger := t.baseLogger()
+				logger.Debug().Err(err).Msg("could not rewind the stdin, the command is not retried")
+			}
+
+			result.Attempts = attempts
+			return result
+		}
+	}
+}
+
+// stdinReplay provides the same stdin to every attempt of the task
+type stdinReplay struct {
+	src io.Reader
+	buf bytes.Buffer
+
+	// file is the seekable stdin file, rewound to the offset before every attempt
+	file   *os.File
+	offset int64
+}
+
+func newStdinReplay(stdin io.Reader) *stdinReplay {
+	r := &stdinReplay{src: stdin}
+	if f, ok := stdin.(*os.File); ok {
+		if offset, err := f.Seek(0, io.SeekCurrent); err == nil {
+			r.file, r.offset = f, offset
+		}
+	}
+
+	return r
+}
+
+// reader returns the stdin of the next attempt, which replays the input read by the
+// previous attempts before reading further
+func (r *stdinReplay) reader() io.Reader {
+	if _, ok := r.src.(*os.File); ok || r.src == nil {
+		return r.src
+	}
+
+	return io.MultiReader(bytes.NewReader(r.buf.Bytes()), io.TeeReader(r.src, &r.buf))
+}
+
+// rewind rewinds the seekable stdin file, if any
+func (r *stdinReplay) rewind() error {
+	if r.file == nil {
+		return nil
+	}
+
+	_, err := r.file.Seek(r.offset, io.SeekStart)
+	return err
+}
@@ -0,0 +1,133 @@
+package exec_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyScript fails with the given exit code until it has been run the given number of times
+const flakyScript = `n=$(cat "$1" 2>/dev/null || echo 0); n=$((n + 1)); echo $n > "$1"
+[ $n -ge $2 ] && echo "ok after $n" && exit 0
+echo "connection reset (attempt $n)" >&2; exit $3`
+
+func flakyTask(t *testing.T, succeedAt, code int, opts ...Option) *Task {
+	counter := filepath.Join(t.TempDir(), "counter")
+	opts = append([]Option{WithShell("/bin/sh"), WithArgs("flaky", counter, strconv.Itoa(succeedAt), strconv.Itoa(code))}, opts...)
+	return MustExec(flakyScript, opts...)
+}
+
+func TestRetry(t *testing.T) {
+	fast := WithBackoff(time.Millisecond, 5*time.Millisecond)
+
+	t.Run("retried until success", func(t *testing.T) {
+		result := flakyTask(t, 3, 1, WithRetry(5, fast)).Execute()
+
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "ok after 3\n", result.Stdout)
+		assert.Len(t, result.Attempts, 3)
+		assert.Equal(t, 1, result.Attempts[0].ExitCode)
+		assert.Equal(t, "connection reset (attempt 2)\n", result.Attempts[1].Stderr)
+		assert.Equal(t, 3, result.Record().Attempts)
+	})
+
+	t.Run("attempts are exhausted", func(t *testing.T) {
+		result := flakyTask(t, 9, 2, WithRetry(3, fast)).Execute()
+
+		assert.Equal(t, 2, result.ExitCode)
+		assert.Len(t, result.Attempts, 3)
+		assert.Equal(t, "connection reset (attempt 3)\n", result.Stderr)
+	})
+
+	t.Run("predicates restrict the retried failures", func(t *testing.T) {
+		result := flakyTask(t, 3, 4, WithRetry(5, fast, RetryIf(RetryOnExitCodes(1, 2)))).Execute()
+		assert.Equal(t, 4, result.ExitCode)
+		assert.Len(t, result.Attempts, 1)
+
+		result = flakyTask(t, 3, 4, WithRetry(5, fast, RetryIf(RetryOnStderr(regexp.MustCompile(`connection reset`))))).Execute()
+		assert.Equal(t, 0, result.ExitCode)
+		assert.Len(t, result.Attempts, 3)
+
+		result = MustExec("sleep", WithArgs("5"),
+			WithTimeout(50*time.Millisecond),
+			WithRetry(2, fast, RetryIf(RetryOnTimeout())),
+		).Execute()
+		assert.True(t, result.TimedOut)
+		assert.Len(t, result.Attempts, 2)
+	})
+
+	t.Run("context done during the backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result := flakyTask(t, 9, 1, WithRetry(5, WithBackoff(time.Minute, time.Minute))).ExecuteContext(ctx)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, 1, result.ExitCode)
+		assert.Len(t, result.Attempts, 1)
+	})
+
+	t.Run("every attempt reads the same stdin", func(t *testing.T) {
+		stdinFile := filepath.Join(t.TempDir(), "stdin")
+		assert.NoError(t, os.WriteFile(stdinFile, []byte("skipped\nmanifest\n"), 0o600))
+
+		f, err := os.Open(stdinFile)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		_, err = f.Seek(int64(len("skipped\n")), io.SeekStart)
+		assert.NoError(t, err)
+
+		for name, stdin := range map[string]io.Reader{
+			"reader": strings.NewReader("manifest\n"),
+			"file":   f,
+		} {
+			counter := filepath.Join(t.TempDir(), "counter")
+			result := MustExec(`n=$(cat "$1" 2>/dev/null || echo 0); echo $((n + 1)) > "$1"; cat; [ $n -ge 2 ]`,
+				WithShell("/bin/sh"),
+				WithArgs("replay", counter),
+				WithStdin(stdin),
+				WithRetry(3, fast),
+			).Execute()
+
+			assert.NoError(t, result.Err(), name)
+			assert.Len(t, result.Attempts, 3, name)
+			for _, attempt := range result.Attempts {
+				assert.Equal(t, "manifest\n", attempt.Stdout, name)
+			}
+		}
+	})
+
+	t.Run("runner retries the tasks", func(t *testing.T) {
+		r, err := NewRunner(WithConcurrency(2))
+		assert.NoError(t, err)
+
+		results, err := r.Run(flakyTask(t, 2, 1, WithRetry(2, fast)), MustExec("true"))
+		assert.NoError(t, err)
+		assert.Len(t, results[0].Attempts, 2)
+		assert.Nil(t, results[1].Attempts)
+	})
+
+	t.Run("invalid retry options", func(t *testing.T) {
+		_, err := NewExec("true", WithRetry(0))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithRetry(3, WithBackoff(time.Second, time.Millisecond)))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithRetry(3, WithJitter(1.5)))
+		assert.Error(t, err)
+
+		_, err = NewExec("true", WithRetry(3, RetryIf(nil)))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+package exec
+
+import "errors"
+
+// errRlimitUnsupported is returned by the rlimit option on the unsupported platforms
+var errRlimitUnsupported = errors.New("resource limits are only supported on Linux")
+
+// RlimInfinity means no limit on the resource
+const RlimInfinity = ^uint64(0)
+
+// RlimitResource represent a resource limited by the rlimit
+type RlimitResource uint8
+
+const (
+	// RlimitCPU limits the CPU time in seconds
+	RlimitCPU RlimitResource = iota + 1
+	// RlimitAS limits the address space (virtual memory) in bytes
+	RlimitAS
+	// RlimitNOFILE limits the number of open files
+	RlimitNOFILE
+	// RlimitNPROC limits the number of processes of the user
+	RlimitNPROC
+	// RlimitCore limits the size of the core dump in bytes
+	RlimitCore
+	// RlimitFSIZE limits the size of the written files in bytes
+	RlimitFSIZE
+)
+
+func (r RlimitResource) String() string {
+	switch r {
+	case RlimitCPU:
+		return "cpu"
+	case RlimitAS:
+		return "as"
+	case RlimitNOFILE:
+		return "nofile"
+	case RlimitNPROC:
+		return "nproc"
+	case RlimitCore:
+		return "core"
+	case RlimitFSIZE:
+		return "fsize"
+	}
+
+	return ""
+}
+
+// rlimit holds the soft and hard limits of a resource
+type rlimit struct {
+	resource RlimitResource
+	soft     uint64
+	hard     uint64
+}
+
+// findRlimit returns the limit set for the resource, if any
+func findRlimit(limits []rlimit, resource RlimitResource) (rlimit, bool) {
+	for _, l := range limits {
+		if l.resource == resource {
+			return l, true
+		}
+	}
+
+	return rlimit{}, false
+}
@@ -0,0 +1,93 @@
+//go:build linux
+
+package exec
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const rlimitSupported = true
+
+var rlimitResources = map[RlimitResource]int{
+	RlimitCPU:    unix.RLIMIT_CPU,
+	RlimitAS:     unix.RLIMIT_AS,
+	RlimitNOFILE: unix.RLIMIT_NOFILE,
+	RlimitNPROC:  unix.RLIMIT_NPROC,
+	RlimitCore:   unix.RLIMIT_CORE,
+	RlimitFSIZE:  unix.RLIMIT_FSIZE,
+}
+
+// cldStopped is the siginfo code of a stopped child
+const cldStopped = 5
+
+// applyRlimits sets the resource limits of the running process. A held process
+// is resumed once its limits are set.
+func applyRlimits(pid int, limits []rlimit, held bool) error {
+	if held {
+		// the process is not reaped, it is left to the command wait
+		var (
+			info unix.Siginfo
+			err  error
+		)
+		for {
+			err = unix.Waitid(unix.P_PID, pid, &info, unix.WSTOPPED|unix.WEXITED|unix.WNOWAIT, nil)
+			if err != unix.EINTR {
+				break
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Code != cldStopped {
+			return fmt.Errorf("the process exited before its limits were set")
+		}
+	}
+
+	for _, l := range limits {
+		lim := &unix.Rlimit{Cur: l.soft, Max: l.hard}
+		if err := unix.Prlimit(pid, rlimitResources[l.resource], lim, nil); err != nil {
+			return err
+		}
+	}
+
+	// the process is left stopped when the limits could not be set, to be killed
+	if held {
+		return unix.Kill(pid, unix.SIGCONT)
+	}
+
+	return nil
+}
+
+// exceededRlimit returns the resource limit which likely caused the termination of the command
+func exceededRlimit(limits []rlimit, result Result) RlimitResource {
+	if len(limits) == 0 || result.Signal == 0 {
+		return 0
+	}
+
+	switch result.Signal {
+	case syscall.SIGXCPU:
+		return RlimitCPU
+	case syscall.SIGXFSZ:
+		return RlimitFSIZE
+	case syscall.SIGKILL:
+		// the process is killed once the CPU time reached the hard limit
+		if l, ok := findRlimit(limits, RlimitCPU); ok && l.hard != RlimInfinity {
+			if result.UserTime+result.SystemTime >= time.Duration(l.hard)*time.Second {
+				return RlimitCPU
+			}
+		}
+	case syscall.SIGSEGV, syscall.SIGABRT, syscall.SIGBUS:
+		// failing to allocate memory commonly ends with one of these signals
+		if _, ok := findRlimit(limits, RlimitAS); ok {
+			return RlimitAS
+		}
+	}
+
+	return 0
+}
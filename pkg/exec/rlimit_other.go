@@ -0,0 +1,15 @@
+//go:build !linux
+
+package exec
+
+const rlimitSupported = false
+
+// applyRlimits is a no-op on this platform
+func applyRlimits(pid int, limits []rlimit, held bool) error {
+	return nil
+}
+
+// exceededRlimit is a no-op on this platform
+func exceededRlimit(limits []rlimit, result Result) RlimitResource {
+	return 0
+}
@@ -0,0 +1,167 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Runner runs many tasks concurrently with a bounded number of workers
+type Runner struct {
+	concurrency int
+	failFast    bool
+	dryRun      bool
+}
+
+// RunnerOption represent the runner option
+type RunnerOption func(*Runner) error
+
+// TaskError describes a task that failed when run by the Runner
+type TaskError struct {
+	// Index is the position of the task in the given tasks
+	Index int
+	// Result is the result of the failed task
+	Result Result
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task #%d (%s) failed with exit code %d", e.Index, e.Result.Command, e.Result.ExitCode)
+}
+
+// Unwrap returns the error of the task result
+func (e *TaskError) Unwrap() error {
+	return e.Result.Err()
+}
+
+// WithConcurrency set the maximum number of tasks running at the same time,
+// by default it is the number of CPUs
+func WithConcurrency(n int) RunnerOption {
+	return func(r *Runner) error {
+		if n <= 0 {
+			return fmt.Errorf("concurrency must be greater than zero")
+		}
+
+		r.concurrency = n
+		return nil
+	}
+}
+
+// WithFailFast cancels the remaining tasks as soon as a task fails,
+// by default every task is run to completion
+func WithFailFast() RunnerOption {
+	return func(r *Runner) error {
+		r.failFast = true
+		return nil
+	}
+}
+
+// WithRunnerDryRun skips the execution of every task, as if each task had the dry-run
+// mode enabled (see WithDryRun)
+func WithRunnerDryRun() RunnerOption {
+	return func(r *Runner) error {
+		r.dryRun = true
+		return nil
+	}
+}
+
+// NewRunner returns a new Runner following with error
+func NewRunner(opts ...RunnerOption) (*Runner, error) {
+	r := &Runner{
+		concurrency: runtime.NumCPU(),
+	}
+
+	for _, o := range opts {
+		if err := o(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Run runs the tasks and waits for all of them to complete
+func (r *Runner) Run(tasks ...*Task) ([]Result, error) {
+	return r.RunContext(context.Background(), tasks...)
+}
+
+// RunContext runs the tasks and waits for all of them to complete, or until the
+// context is done. The results are returned in the same order of the tasks, along
+// with the aggregated *TaskError of every failed task.
+func (r *Runner) RunContext(ctx context.Context, tasks ...*Task) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   bool
+		results  = make([]Result, len(tasks))
+		failures = make([]bool, len(tasks))
+		indexc   = make(chan int)
+	)
+
+	workers := r.concurrency
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexc {
+				result := r.execute(ctx, tasks[i])
+				results[i] = result
+
+				if !failedResult(result) {
+					continue
+				}
+
+				mu.Lock()
+				// the tasks canceled due to fail-fast are not the cause of the failure
+				if !(failed && r.failFast && result.Canceled) {
+					failures[i] = true
+				}
+
+				failed = true
+				mu.Unlock()
+
+				if r.failFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		indexc <- i
+	}
+	close(indexc)
+	wg.Wait()
+
+	var errs []error
+	for i, result := range results {
+		if failures[i] {
+			errs = append(errs, &TaskError{Index: i, Result: result})
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// failedResult reports whether the result is considered as a failure
+func failedResult(result Result) bool {
+	return result.ExitCode != 0 || result.Canceled || result.TimedOut
+}
+
+// execute runs the task, unless the dry-run mode is enabled
+func (r *Runner) execute(ctx context.Context, t *Task) Result {
+	if r.dryRun {
+		return t.dryRunResult()
+	}
+
+	return t.ExecuteContext(ctx)
+}
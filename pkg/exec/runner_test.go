@@ -0,0 +1,91 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRunner(t *testing.T) {
+	r, err := NewRunner(WithConcurrency(0))
+	assert.Nil(t, r)
+	assert.Error(t, err)
+
+	r, err = NewRunner(WithConcurrency(2), WithFailFast())
+	assert.NotNil(t, r)
+	assert.NoError(t, err)
+}
+
+func TestRunnerRun(t *testing.T) {
+	t.Run("results are in the tasks order", func(t *testing.T) {
+		tasks := []*Task{}
+		for i := 0; i < 10; i++ {
+			// the earlier tasks complete later
+			tasks = append(tasks, MustExec(fmt.Sprintf("sleep 0.0%d; echo %d", 9-i, i), WithShell("/bin/sh")))
+		}
+
+		r, err := NewRunner(WithConcurrency(4))
+		assert.NoError(t, err)
+
+		results, err := r.Run(tasks...)
+		assert.NoError(t, err)
+		assert.Len(t, results, 10)
+		for i, result := range results {
+			assert.Equal(t, fmt.Sprintf("%d\n", i), result.Stdout)
+		}
+	})
+
+	t.Run("run to completion aggregates every failure", func(t *testing.T) {
+		r, err := NewRunner(WithConcurrency(2))
+		assert.NoError(t, err)
+
+		results, err := r.Run(
+			MustExec("exit 1", WithShell("/bin/sh")),
+			MustExec("echo ok", WithShell("/bin/sh")),
+			MustExec("exit 2", WithShell("/bin/sh")),
+		)
+		assert.Error(t, err)
+		assert.Equal(t, "ok\n", results[1].Stdout)
+
+		var taskErr *TaskError
+		assert.True(t, errors.As(err, &taskErr))
+		assert.Equal(t, 0, taskErr.Index)
+		assert.Contains(t, err.Error(), "task #2")
+	})
+
+	t.Run("fail fast cancels the remaining tasks", func(t *testing.T) {
+		r, err := NewRunner(WithConcurrency(2), WithFailFast())
+		assert.NoError(t, err)
+
+		start := time.Now()
+		results, err := r.Run(
+			MustExec("sleep", WithArgs("5")),
+			MustExec("exit 3", WithShell("/bin/sh")),
+			MustExec("echo", WithArgs("never")),
+		)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Error(t, err)
+		assert.True(t, results[0].Canceled)
+		assert.Equal(t, 3, results[1].ExitCode)
+		assert.True(t, results[2].Canceled)
+		assert.Empty(t, results[2].Stdout)
+		assert.NotContains(t, err.Error(), "task #0")
+	})
+
+	t.Run("runner canceled by context", func(t *testing.T) {
+		r, err := NewRunner()
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		results, err := r.RunContext(ctx, MustExec("sleep", WithArgs("5")))
+		assert.Error(t, err)
+		assert.True(t, results[0].TimedOut)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package exec
+
+import (
+	"os"
+	"syscall"
+)
+
+// maxRSS returns the maximum resident set size of the exited process in bytes
+func maxRSS(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// the resident set size is reported in kilobytes on Linux
+		return int64(ru.Maxrss) * 1024
+	}
+
+	return 0
+}
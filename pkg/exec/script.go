@@ -0,0 +1,235 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ardikabs/go-stdlib/pkg/shellwords"
+)
+
+// scriptLineMarker is written to stderr by the shells supporting the ERR trap,
+// to report the line of the failed command
+const scriptLineMarker = "__exec_script_failed_at_line__"
+
+var scriptLineRx = regexp.MustCompile(`(?m)^` + scriptLineMarker + `:(\d+)\n?`)
+
+// NewScript returns a new Task running the multi-line script, rendered from the
+// text/template body with the variables. Every printed value is quoted for the shell,
+// a []string as separate words, use {{ raw .name }} to print a value as is.
+//
+// As the values are quoted, they must not be printed within a quoted string: the template
+// fails with an error on `echo "hello {{ .name }}"`, which would print the quotes of the value.
+// Write `echo hello {{ .name }}` instead, or `echo "hello {{ raw .name }}"` for a trusted value.
+//
+// The script runs with the shell set by WithShell, by default /bin/sh, with the strict
+// flags (errexit, nounset and pipefail when supported). With bash, zsh or ksh, the line
+// of the failed command is reported in the Result.
+func NewScript(body string, vars map[string]any, opts ...Option) (*Task, error) {
+	rendered, err := renderScript(body, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := NewExec(rendered, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.shellExec == "" {
+		t.shellExec = "/bin/sh"
+	}
+
+	t.shellMode = true
+	t.script = true
+	// the prelude takes a single line, so that the body starts on the second line
+	t.command = scriptPrelude(t.shellExec) + "\n" + rendered
+
+	return t, nil
+}
+
+// MustScript is like NewScript but panics on error
+func MustScript(body string, vars map[string]any, opts ...Option) *Task {
+	t, err := NewScript(body, vars, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// renderScript renders the script body, every printed value being quoted for the shell
+func renderScript(body string, vars map[string]any) (string, error) {
+	tmpl, err := template.New("script").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"quote": quoteScriptValue,
+			"raw":   rawScriptValue,
+		}).
+		Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("invalid script template: %w", err)
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+
+		if err := quoteActions(t.Tree, t.Tree.Root, &shellQuoteState{}); err != nil {
+			return "", fmt.Errorf("invalid script template: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("could not render the script: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// quoteActions pipes every printing action of the template into the quote function,
+// unless the action already ends with the quote or the raw function. The quote state of the
+// shell is tracked along the text of the template, in the source order, so that an action
+// to quote within a quoted string, where its quotes would be printed as is, is rejected.
+func quoteActions(tree *parse.Tree, node parse.Node, st *shellQuoteState) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, child := range n.Nodes {
+			if err := quoteActions(tree, child, st); err != nil {
+				return err
+			}
+		}
+	case *parse.TextNode:
+		st.scan(string(n.Text))
+	case *parse.IfNode:
+		return quoteBranches(tree, n.List, n.ElseList, st)
+	case *parse.RangeNode:
+		return quoteBranches(tree, n.List, n.ElseList, st)
+	case *parse.WithNode:
+		return quoteBranches(tree, n.List, n.ElseList, st)
+	case *parse.ActionNode:
+		// the variable declarations print nothing
+		if len(n.Pipe.Decl) > 0 {
+			return nil
+		}
+
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok && (id.Ident == "quote" || id.Ident == "raw") {
+			return nil
+		}
+
+		if st.quote != 0 {
+			location, action := tree.ErrorContext(n)
+			return fmt.Errorf("%s: %s is within a %c-quoted string, where the quotes of the value would be printed as is, "+
+				"move it out of the quoted string, or print it as is with raw", location, action, st.quote)
+		}
+
+		quote := parse.NewIdentifier("quote").SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{quote},
+		})
+	}
+
+	return nil
+}
+
+func quoteBranches(tree *parse.Tree, list, elseList *parse.ListNode, st *shellQuoteState) error {
+	if err := quoteActions(tree, list, st); err != nil {
+		return err
+	}
+
+	return quoteActions(tree, elseList, st)
+}
+
+// shellQuoteState tracks whether the shell text is within a quoted string or a comment
+type shellQuoteState struct {
+	// quote is the opening quote of the current string, zero outside of a string
+	quote   byte
+	escaped bool
+	comment bool
+	// prev is the previous character, a comment only starts at the beginning of a word
+	prev byte
+}
+
+func (st *shellQuoteState) scan(text string) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+
+		switch {
+		case st.comment:
+			st.comment = c != '\n'
+		case st.escaped:
+			st.escaped = false
+		case st.quote == '\'':
+			if c == '\'' {
+				st.quote = 0
+			}
+		case c == '\\':
+			st.escaped = true
+		case st.quote == '"':
+			if c == '"' {
+				st.quote = 0
+			}
+		case c == '\'', c == '"':
+			st.quote = c
+		case c == '#' && (st.prev == 0 || strings.IndexByte(" \t\n;&|()", st.prev) >= 0):
+			st.comment = true
+		}
+
+		st.prev = c
+	}
+}
+
+// quoteScriptValue returns the value quoted for the shell, a []string as separate words
+func quoteScriptValue(v any) string {
+	if words, ok := v.([]string); ok {
+		return shellwords.Join(words)
+	}
+
+	return shellwords.Quote(fmt.Sprint(v))
+}
+
+// rawScriptValue returns the value as is, a []string joined with spaces
+func rawScriptValue(v any) string {
+	if words, ok := v.([]string); ok {
+		return strings.Join(words, " ")
+	}
+
+	return fmt.Sprint(v)
+}
+
+// scriptPrelude returns the single line setting the strict flags of the shell
+func scriptPrelude(shell string) string {
+	switch filepath.Base(shell) {
+	case "bash", "zsh", "ksh":
+		return fmt.Sprintf(`set -eEuo pipefail; trap 'echo "%s:$((LINENO - 1))" >&2' ERR`, scriptLineMarker)
+	default:
+		return "set -eu; (set -o pipefail) 2>/dev/null && set -o pipefail"
+	}
+}
+
+// scriptFailedLine extracts the line of the failed command from the stderr,
+// and returns the stderr without the marker
+func scriptFailedLine(stderr string) (int, string) {
+	m := scriptLineRx.FindAllStringSubmatch(stderr, -1)
+	if len(m) == 0 {
+		return 0, stderr
+	}
+
+	// the first reported line is the failed command, the subsequent ones are its callers
+	line, _ := strconv.Atoi(m[0][1])
+	return line, scriptLineRx.ReplaceAllString(stderr, "")
+}
@@ -0,0 +1,116 @@
+package exec_test
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/ardikabs/go-stdlib/pkg/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScript(t *testing.T) {
+	t.Run("quoting the variables", func(t *testing.T) {
+		tc, err := NewScript(`
+printf '%s\n' {{ .name }}
+printf '%s\n' {{ .files }}
+echo {{ raw .flag }}
+`, map[string]any{
+			"name":  "it's $(whoami); rm -rf /",
+			"files": []string{"a b", "c"},
+			"flag":  "-n",
+		})
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, 0, result.ExitCode, result.Stderr)
+		assert.Equal(t, "it's $(whoami); rm -rf /\na b\nc\n", result.Stdout)
+	})
+
+	t.Run("conditions and loops over the variables", func(t *testing.T) {
+		tc, err := NewScript(`
+{{ if .verbose }}echo verbose{{ else }}echo quiet{{ end }}
+{{ range $i, $f := .files }}printf '%s|%s\n' {{ $i }} {{ $f }}
+{{ end }}{{ with .name }}echo {{ . }}{{ end }}
+{{ define "greet" }}echo {{ . }}{{ end }}{{ template "greet" .name }}
+`, map[string]any{
+			"verbose": false,
+			"files":   []string{"a b", "it's"},
+			"name":    "$HOME",
+		})
+		assert.NoError(t, err)
+
+		result := tc.Execute()
+		assert.Equal(t, 0, result.ExitCode, result.Stderr)
+		assert.Equal(t, "quiet\n0|a b\n1|it's\n$HOME\n$HOME\n", result.Stdout)
+	})
+
+	t.Run("rejecting the values within a quoted string", func(t *testing.T) {
+		_, err := NewScript(`echo "hello {{ .name }}"`, map[string]any{"name": "big world"})
+		assert.ErrorContains(t, err, "within a \"-quoted string")
+
+		_, err = NewScript(`echo 'hello {{ .name }}'`, map[string]any{"name": "big world"})
+		assert.ErrorContains(t, err, "within a '-quoted string")
+
+		result := MustScript(`
+# it's a comment
+echo "it's" hello\" {{ .name }}
+echo "hello {{ raw .name }}"
+`, map[string]any{"name": "big world"}).Execute()
+		assert.Equal(t, 0, result.ExitCode, result.Stderr)
+		assert.Equal(t, "it's hello\" big world\nhello big world\n", result.Stdout)
+	})
+
+	t.Run("stopping on the first failure", func(t *testing.T) {
+		result := MustScript(`
+false
+echo unreachable
+`, nil).Execute()
+
+		assert.Equal(t, 1, result.ExitCode)
+		assert.Empty(t, result.Stdout)
+	})
+
+	t.Run("failing on unset variables", func(t *testing.T) {
+		result := MustScript(`echo "$EXEC_TEST_UNSET_VARIABLE"`, nil).Execute()
+		assert.NotEqual(t, 0, result.ExitCode)
+	})
+
+	t.Run("reporting the failed line", func(t *testing.T) {
+		if _, err := exec.LookPath("bash"); err != nil {
+			t.Skip("requires bash")
+		}
+
+		result := MustScript(`
+echo one
+true | true
+ls {{ .path }}
+echo unreachable
+`, map[string]any{"path": "/does/not/exist"}, WithShell("bash")).Execute()
+
+		assert.NotEqual(t, 0, result.ExitCode)
+		assert.Equal(t, 4, result.FailedLine)
+		assert.Equal(t, "one\n", result.Stdout)
+		assert.Contains(t, result.Stderr, "/does/not/exist")
+		assert.NotContains(t, result.Stderr, "__exec_script")
+		assert.ErrorContains(t, result.Err(), "at line 4")
+	})
+
+	t.Run("failing the pipeline", func(t *testing.T) {
+		if _, err := exec.LookPath("bash"); err != nil {
+			t.Skip("requires bash")
+		}
+
+		result := MustScript("false | true\necho unreachable", nil, WithShell("bash")).Execute()
+		assert.Equal(t, 1, result.ExitCode)
+		assert.Equal(t, 1, result.FailedLine)
+		assert.Empty(t, result.Stdout)
+	})
+
+	t.Run("invalid templates", func(t *testing.T) {
+		_, err := NewScript(`echo {{ .name `, nil)
+		assert.Error(t, err)
+
+		_, err = NewScript(`echo {{ .missing }}`, map[string]any{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,38 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// terminate asks the process to exit with SIGTERM and waits for the grace period
+// before killing it with SIGKILL
+func (t *Task) terminate(cmd *exec.Cmd, waitc <-chan error) error {
+	if t.gracePeriod <= 0 || t.signal(cmd, syscall.SIGTERM) != nil {
+		_ = t.signal(cmd, syscall.SIGKILL)
+		return <-waitc
+	}
+
+	timer := time.NewTimer(t.gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case err := <-waitc:
+		return err
+	case <-timer.C:
+		_ = t.signal(cmd, syscall.SIGKILL)
+		return <-waitc
+	}
+}
+
+// signal sends the signal to the command, or to its whole process group
+// when the process group management is enabled
+func (t *Task) signal(cmd *exec.Cmd, sig os.Signal) error {
+	if s, ok := sig.(syscall.Signal); ok && t.processGroup {
+		return signalProcessGroup(cmd, s)
+	}
+
+	return cmd.Process.Signal(sig)
+}
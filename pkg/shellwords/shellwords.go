@@ -0,0 +1,148 @@
+// Package shellwords splits a command line into words following the POSIX shell
+// quoting rules, and quotes words back into a command line.
+//
+// No expansion is performed: variables, command substitutions and globs are kept as is,
+// and the unquoted shell operators (such as | ; & < > ( ) and `) are rejected,
+// as they require a shell to be interpreted.
+package shellwords
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnterminatedQuote is returned when a quote is not closed
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+
+	// ErrTrailingBackslash is returned when the line ends with an escaping backslash
+	ErrTrailingBackslash = errors.New("trailing backslash")
+
+	// ErrUnsupportedOperator is returned when the line contains an unquoted shell operator
+	ErrUnsupportedOperator = errors.New("unsupported shell operator")
+)
+
+// safeWord matches the words which need no quoting
+var safeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Split splits the command line into words
+func Split(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		runes   = []rune(line)
+		n       = len(runes)
+		quoteAt int
+	)
+
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		r := runes[i]
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+
+		case r == '#' && !inWord:
+			// the comment lasts until the end of the line
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '\\':
+			if i+1 >= n {
+				return nil, ErrTrailingBackslash
+			}
+
+			i++
+			// an escaped newline is a line continuation
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+
+		case r == '\'':
+			inWord = true
+			quoteAt = i
+			for i++; i < n && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+
+			if i >= n {
+				return nil, fmt.Errorf("%w at position %d", ErrUnterminatedQuote, quoteAt)
+			}
+
+		case r == '"':
+			inWord = true
+			quoteAt = i
+			for i++; i < n && runes[i] != '"'; i++ {
+				// within double quotes, the backslash escapes only $ ` " \ and newline
+				if runes[i] == '\\' && i+1 < n && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+
+				word.WriteRune(runes[i])
+			}
+
+			if i >= n {
+				return nil, fmt.Errorf("%w at position %d", ErrUnterminatedQuote, quoteAt)
+			}
+
+		case strings.ContainsRune("|&;<>()`", r):
+			return nil, fmt.Errorf("%w %q at position %d", ErrUnsupportedOperator, r, i)
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	flush()
+	return words, nil
+}
+
+// Quote quotes the word to be safely used in the shell
+func Quote(word string) string {
+	if word == "" {
+		return "''"
+	}
+
+	if safeWord.MatchString(word) {
+		return word
+	}
+
+	return singleQuote(word)
+}
+
+func singleQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// Join quotes and joins the words into a copy-pasteable command line. The first word
+// containing an `=` is always quoted, so that it is not read as a variable assignment.
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		if i == 0 && strings.Contains(word, "=") {
+			quoted[i] = singleQuote(word)
+			continue
+		}
+
+		quoted[i] = Quote(word)
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,67 @@
+package shellwords_test
+
+import (
+	"testing"
+
+	"github.com/ardikabs/go-stdlib/pkg/shellwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	testcases := []struct {
+		line string
+		want []string
+		err  error
+	}{
+		{line: "", want: nil},
+		{line: "  git   status  ", want: []string{"git", "status"}},
+		{line: `git log --format="%H %s"`, want: []string{"git", "log", "--format=%H %s"}},
+		{line: `echo 'it'\''s' "a \"b\" \$c \d"`, want: []string{"echo", "it's", `a "b" $c \d`}},
+		{line: `echo '' ""`, want: []string{"echo", "", ""}},
+		{line: `echo a\ b c\\d`, want: []string{"echo", "a b", `c\d`}},
+		{line: "echo one \\\n two", want: []string{"echo", "one", "two"}},
+		{line: "echo $HOME *.go # a comment\nls", want: []string{"echo", "$HOME", "*.go", "ls"}},
+		{line: "echo a#b", want: []string{"echo", "a#b"}},
+		{line: `echo "unterminated`, err: shellwords.ErrUnterminatedQuote},
+		{line: `echo 'unterminated`, err: shellwords.ErrUnterminatedQuote},
+		{line: `echo \`, err: shellwords.ErrTrailingBackslash},
+		{line: `cat file | grep x`, err: shellwords.ErrUnsupportedOperator},
+		{line: `echo $(whoami)`, err: shellwords.ErrUnsupportedOperator},
+		{line: `echo "a | b; c"`, want: []string{"echo", "a | b; c"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.line, func(t *testing.T) {
+			got, err := shellwords.Split(tc.line)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	words := []string{"git", "log", "--format=%H %s", "it's", "", "a\nb", "$HOME"}
+
+	line := shellwords.Join(words)
+	assert.Equal(t, `git log '--format=%H %s' 'it'\''s' '' 'a`+"\n"+`b' '$HOME'`, line)
+
+	got, err := shellwords.Split(line)
+	assert.NoError(t, err)
+	assert.Equal(t, words, got)
+
+	t.Run("first word with an assignment", func(t *testing.T) {
+		words := []string{"FOO=bar", "x", "A=1"}
+
+		line := shellwords.Join(words)
+		assert.Equal(t, `'FOO=bar' x A=1`, line)
+
+		got, err := shellwords.Split(line)
+		assert.NoError(t, err)
+		assert.Equal(t, words, got)
+	})
+}